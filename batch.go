@@ -0,0 +1,83 @@
+package gotextfsm
+
+import (
+	"runtime"
+	"sync"
+)
+
+// BatchResult holds the result of parsing one input of a batch.
+//     Index: position of the input in the batch.
+//     Dict: records parsed from the input. nil if there was an error.
+//     Err: error while parsing the input. nil otherwise.
+type BatchResult struct {
+	Index int
+	Dict  []map[string]interface{}
+	Err   error
+}
+
+// ParseTextBatch parses each of the inputs through the same FSM using a pool of goroutines.
+//     Args:
+//       fsm: (TextFSM), TextFSM object as a result of parsing the text fsm template
+//       inputs: ([]string), Texts to parse. Each one is parsed independently with EOF semantics.
+//       workers: (int), Number of goroutines to use. runtime.NumCPU() if < 1.
+//     Returns:
+//       One BatchResult per input, in the same order as the inputs.
+func ParseTextBatch(fsm TextFSM, inputs []string, workers int) []BatchResult {
+	ch := make(chan string)
+	go func() {
+		for _, input := range inputs {
+			ch <- input
+		}
+		close(ch)
+	}()
+	return ParseTextChannel(fsm, ch, workers)
+}
+
+// ParseTextChannel is same as ParseTextBatch. But reads the inputs from a channel until it is closed.
+// The results are returned in the order the inputs were received.
+func ParseTextChannel(fsm TextFSM, inputs <-chan string, workers int) []BatchResult {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	type job struct {
+		index int
+		text  string
+	}
+	jobs := make(chan job)
+	results := make(chan BatchResult)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				out := ParserOutput{}
+				result := BatchResult{Index: j.index}
+				if err := out.ParseTextString(j.text, fsm, true); err != nil {
+					result.Err = err
+				} else {
+					result.Dict = out.Dict
+				}
+				results <- result
+			}
+		}()
+	}
+	go func() {
+		index := 0
+		for text := range inputs {
+			jobs <- job{index: index, text: text}
+			index++
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+	output := make([]BatchResult, 0)
+	for result := range results {
+		for len(output) <= result.Index {
+			output = append(output, BatchResult{})
+		}
+		output[result.Index] = result
+	}
+	return output
+}
@@ -0,0 +1,96 @@
+package gotextfsm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseTextBatch(t *testing.T) {
+	template := `Value Filldown device (\S+)
+Value Required interface (\S+)
+Value List vlans (\d+)
+
+Start
+  ^Device: ${device}
+  ^Interface ${interface}
+  ^\s+vlan ${vlans}
+  ^\s+bad -> Error "bad input"
+  ^$$ -> Record
+`
+	fsm := TextFSM{}
+	if err := fsm.ParseString(template); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	inputs := make([]string, 0)
+	for i := 0; i < 200; i++ {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Device: dev%d\n", i))
+		for j := 0; j <= i%5; j++ {
+			sb.WriteString(fmt.Sprintf("Interface eth%d\n  vlan %d\n  vlan %d\n\n", j, i, j))
+		}
+		if i%7 == 0 {
+			sb.WriteString("  bad\n")
+		}
+		inputs = append(inputs, sb.String())
+	}
+	for _, workers := range []int{0, 1, 3, 16} {
+		results := ParseTextBatch(fsm, inputs, workers)
+		if len(results) != len(inputs) {
+			t.Fatalf("Workers %d: Expected %d results. Got %d", workers, len(inputs), len(results))
+		}
+		for i, result := range results {
+			out := ParserOutput{}
+			err := out.ParseTextString(inputs[i], fsm, true)
+			if result.Index != i {
+				t.Errorf("Workers %d: Result %d has index %d", workers, i, result.Index)
+			}
+			if err != nil {
+				if result.Err == nil || result.Err.Error() != err.Error() || result.Dict != nil {
+					t.Errorf("Workers %d: Result %d expected error '%s'. Got '%v'", workers, i, err, result.Err)
+				}
+				continue
+			}
+			if result.Err != nil {
+				t.Errorf("Workers %d: Result %d expected no error. Got '%s'", workers, i, result.Err)
+				continue
+			}
+			tc := parseTestCase{name: fmt.Sprintf("Batch %d", i)}
+			if len(result.Dict) != len(out.Dict) {
+				t.Errorf("Workers %d: Result %d expected %d records. Got %d", workers, i, len(out.Dict), len(result.Dict))
+				continue
+			}
+			for idx, rec := range out.Dict {
+				if msg := comparedicts(tc, rec, result.Dict[idx], idx); msg != "" {
+					t.Error(msg)
+				}
+			}
+		}
+	}
+}
+
+func TestParseTextChannel(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString("Value boo (.*)\n\nStart\n  ^$boo -> Record\n"); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	ch := make(chan string)
+	go func() {
+		for i := 0; i < 50; i++ {
+			ch <- fmt.Sprintf("line %d", i)
+		}
+		close(ch)
+	}()
+	results := ParseTextChannel(fsm, ch, 4)
+	if len(results) != 50 {
+		t.Fatalf("Expected 50 results. Got %d", len(results))
+	}
+	for i, result := range results {
+		if result.Err != nil || len(result.Dict) != 1 || result.Dict[0]["boo"] != fmt.Sprintf("line %d", i) {
+			t.Errorf("Result %d dont match. Got %v, %v", i, result.Dict, result.Err)
+		}
+	}
+	if fsm.Values["boo"].curval != nil {
+		t.Errorf("Parsing should not modify the values of the TextFSM")
+	}
+}
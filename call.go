@@ -0,0 +1,80 @@
+package gotextfsm
+
+import "fmt"
+
+// Default maximum depth of the states called with 'Call'.
+const DEFAULT_MAX_CALL_DEPTH = 16
+
+// changeState moves FSM to the new state of the rule.
+//     'Call State': The current state is pushed to the stack and FSM moves to State.
+//     'Return': FSM moves back to the state popped from the stack. (The state that called the current state)
+// Returns error if the stack is deeper than MaxCallDepth or if there is nothing to return to.
+func (t *ParserOutput) changeState(rule TextFSMRule) error {
+	switch {
+	case rule.Call:
+		max_depth := t.MaxCallDepth
+		if max_depth <= 0 {
+			max_depth = DEFAULT_MAX_CALL_DEPTH
+		}
+		if len(t.stack) >= max_depth {
+			return fmt.Errorf("%d Line: Call stack overflow calling %s. Max depth %d. Rule Line: %d", t.line_num, rule.NewState, max_depth, rule.LineNum)
+		}
+		t.stack = append(t.stack, t.cur_state_name)
+		t.cur_state_name = rule.NewState
+	case rule.NewState == "Return":
+		if len(t.stack) == 0 {
+			return fmt.Errorf("%d Line: Return without Call in state %s. Rule Line: %d", t.line_num, t.cur_state_name, rule.LineNum)
+		}
+		t.cur_state_name = t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+	default:
+		t.cur_state_name = rule.NewState
+	}
+	return nil
+}
+
+// checkCalls returns error if a state can be called again before it returns. ie. if there is a cycle
+// of transitions (plain or 'Call') that contains a 'Call'. The call stack would grow without bound on such a cycle.
+// Transitions to 'Return' are not followed, as they take FSM back to the state that made the call.
+func (t *TextFSM) checkCalls() error {
+	for _, name := range t.state_names {
+		state, exists := t.States[name]
+		if !exists {
+			continue
+		}
+		for _, rule := range state.rules {
+			if !rule.Call {
+				continue
+			}
+			if t.reachable(rule.NewState, name) {
+				t.line_num = rule.LineNum
+				return fmt.Errorf("Recursive Call of state '%s' from state '%s'", rule.NewState, name)
+			}
+		}
+	}
+	return nil
+}
+
+// reachable returns true if state 'to' can be reached from state 'from' through the transitions of the rules.
+func (t *TextFSM) reachable(from string, to string) bool {
+	visited := make(map[string]bool)
+	pending := []string{from}
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if name == to {
+			return true
+		}
+		if visited[name] {
+			continue
+		}
+		visited[name] = true
+		for _, rule := range t.States[name].rules {
+			if rule.LineOp == "Error" || rule.NewState == "" || rule.NewState == "Return" {
+				continue
+			}
+			pending = append(pending, rule.NewState)
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package gotextfsm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// AddressFamily is called from both Global and Vrf and returns to the caller.
+const callTemplate = `Value Filldown Vrf (\S+)
+Value Required Family (\S+)
+Value List Networks (\S+)
+
+Start
+  ^router bgp -> Global
+
+Global
+  ^ vrf ${Vrf} -> Vrf
+  ^ address-family ${Family} -> Call AddressFamily
+
+Vrf
+  ^  address-family ${Family} -> Call AddressFamily
+  ^ exit-vrf -> Clearall Global
+
+AddressFamily
+  ^\s+network ${Networks}
+  ^\s+exit-address-family -> Record Return
+`
+
+const callData = `router bgp 65000
+ address-family ipv4
+  network 10.0.0.0/8
+  network 10.1.0.0/16
+ exit-address-family
+ vrf red
+  address-family ipv6
+   network 2001:db8::/32
+  exit-address-family
+ exit-vrf
+ address-family l2vpn
+ exit-address-family
+`
+
+func TestCall(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString(callTemplate); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	out := ParserOutput{}
+	if err := out.ParseTextString(callData, fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	expected := []map[string]interface{}{
+		{"Vrf": "", "Family": "ipv4", "Networks": []string{"10.0.0.0/8", "10.1.0.0/16"}},
+		{"Vrf": "red", "Family": "ipv6", "Networks": []string{"2001:db8::/32"}},
+		{"Vrf": "", "Family": "l2vpn", "Networks": []string{}},
+	}
+	if !reflect.DeepEqual(out.Dict, expected) {
+		t.Errorf("Records dont match (%v, %v)", expected, out.Dict)
+	}
+
+	// Snapshot taken inside a called state.
+	lines := strings.SplitAfter(callData, "\n")
+	first := ParserOutput{}
+	if err := first.ParseTextString(strings.Join(lines[:8], ""), fsm, false); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	if !reflect.DeepEqual(first.stack, []string{"Vrf"}) {
+		t.Errorf("Stacks dont match (%v, %v)", []string{"Vrf"}, first.stack)
+	}
+	data, err := first.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed with error '%s'", err)
+	}
+	restored := ParserOutput{}
+	if err := restored.Restore(data, fsm); err != nil {
+		t.Fatalf("Restore failed with error '%s'", err)
+	}
+	if err := restored.ParseTextString(strings.Join(lines[8:], ""), fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	if !reflect.DeepEqual(restored.Dict, expected) {
+		t.Errorf("Restored records dont match (%v, %v)", expected, restored.Dict)
+	}
+}
+
+func TestCallRules(t *testing.T) {
+	cases := []struct {
+		rule     string
+		call     bool
+		newState string
+		target   string
+	}{
+		{"  ^a -> Call Sub", true, "Sub", ""},
+		{"  ^a -> Next.Record Call Sub", true, "Sub", ""},
+		{"  ^a -> Record Things Call Sub", true, "Sub", "Things"},
+		{"  ^a -> Record Return", false, "Return", ""},
+		{"  ^a -> Return", false, "Return", ""},
+		{"  ^a -> Record Sub", false, "Sub", ""},
+	}
+	for _, tc := range cases {
+		rule := TextFSMRule{levels: map[string]string{"Things": "Things"}}
+		if err := rule.Parse(tc.rule, 1, nil); err != nil {
+			t.Errorf("'%s' failed with error '%s'", tc.rule, err)
+			continue
+		}
+		if rule.Call != tc.call || rule.NewState != tc.newState || rule.RecordTarget != tc.target {
+			t.Errorf("'%s' failed. Rules dont match (%v, '%s', '%s') (%v, '%s', '%s')", tc.rule, tc.call, tc.newState, tc.target,
+				rule.Call, rule.NewState, rule.RecordTarget)
+		}
+		if rule.String() != " "+strings.TrimSpace(tc.rule) {
+			t.Errorf("'%s' failed. Strings dont match ('%s')", tc.rule, rule.String())
+		}
+	}
+	for _, bad := range []string{"  ^a -> Continue Call Sub", "  ^a -> Error Call Sub", "  ^a -> Call Return"} {
+		rule := TextFSMRule{}
+		if err := rule.Parse(bad, 1, nil); err == nil {
+			t.Errorf("'%s' failed. Expected error", bad)
+		}
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		template string
+		err      string
+	}{
+		{
+			name:     "Recursive call",
+			template: "Value Foo (\\S+)\n\nStart\n  ^a -> Call Sub\n\nSub\n  ^b -> Call Sub\n  ^c -> Return\n",
+			err:      "Recursive Call of state 'Sub' from state 'Sub'",
+		},
+		{
+			name:     "Recursive call through a transition",
+			template: "Value Foo (\\S+)\n\nStart\n  ^a -> Call Sub\n\nSub\n  ^b -> Other\n\nOther\n  ^c -> Start\n  ^d -> Return\n",
+			err:      "Recursive Call of state 'Sub' from state 'Start'",
+		},
+		{
+			name:     "Call of undefined state",
+			template: "Value Foo (\\S+)\n\nStart\n  ^a -> Call Sub\n",
+			err:      "State 'Sub' not found, referenced in state 'Start'",
+		},
+		{
+			name:     "Call of End",
+			template: "Value Foo (\\S+)\n\nStart\n  ^a -> Call End\n",
+			err:      "State 'End' can not be called, referenced in state 'Start'",
+		},
+		{
+			name:     "State named Call",
+			template: "Value Foo (\\S+)\n\nStart\n  ^a -> Call Sub\n\nCall\n  ^b\n",
+			err:      "6 Line: state 'Call' can not be a keyword",
+		},
+	}
+	for _, tc := range cases {
+		fsm := TextFSM{}
+		err := fsm.ParseString(tc.template)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("'%s' failed. Errors dont match ('%s', '%v')", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestCallStack(t *testing.T) {
+	template := "Value Foo (\\S+)\n\nStart\n  ^a -> Call Sub\n  ^x -> Return\n\nSub\n  ^b -> Call Leaf\n  ^c -> Return\n\nLeaf\n  ^d -> Return\n"
+	fsm := TextFSM{}
+	if err := fsm.ParseString(template); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	out := ParserOutput{}
+	if err := out.ParseTextString("a\nb\nd\nc\n", fsm, true); err != nil {
+		t.Errorf("Parsing failed with error '%s'", err)
+	}
+	out = ParserOutput{MaxCallDepth: 1}
+	err := out.ParseTextString("a\nb\n", fsm, true)
+	if err == nil || err.Error() != "2 Line: Call stack overflow calling Leaf. Max depth 1. Rule Line: 8" {
+		t.Errorf("Errors dont match. Got '%v'", err)
+	}
+	out = ParserOutput{}
+	err = out.ParseTextString("x\n", fsm, true)
+	if err == nil || err.Error() != "1 Line: Return without Call in state Start. Rule Line: 5" {
+		t.Errorf("Errors dont match. Got '%v'", err)
+	}
+}
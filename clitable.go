@@ -0,0 +1,243 @@
+package gotextfsm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// CliTableIndex is the index file of CliTable (ex. 'index' of ntc-templates).
+// It maps the attributes of a command (ex. Platform, Command) to the templates that parse the output of the command.
+//
+// The index is a comma separated table. Lines starting with '#' and blank lines are ignored.
+// The first line is the header. Column 'Template' holds the template file names separated by ':'.
+// Every other column holds a regex that must match (from the start) the attribute with the same name.
+// In 'Command' column, '[[xyz]]' marks the optional completion of a command. ex. 'sh[[ow]] ver[[sion]]'
+// matches 'sh ver', 'show vers' and 'show version'.
+type CliTableIndex struct {
+	Header []string
+	rows   []cliTableRow
+}
+
+type cliTableRow struct {
+	templates []string
+	values    map[string]*regexp.Regexp
+}
+
+// Completion of commands. ex. [[ow]]
+var COMPLETION_RE = regexp.MustCompile(`\[\[(.+?)\]\]`)
+
+// ParseCliTableIndex reads an index file of CliTable.
+//     Returns:
+//       error if the index can not be read or is not well formed.
+func ParseCliTableIndex(reader io.Reader) (*CliTableIndex, error) {
+	index := &CliTableIndex{}
+	scanner := bufio.NewScanner(reader)
+	line_num := 0
+	for scanner.Scan() {
+		line_num++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if index.Header == nil {
+			if FindIndex(fields, "Template") < 0 {
+				return nil, fmt.Errorf("Line %d: Index header must have 'Template' column", line_num)
+			}
+			index.Header = fields
+			continue
+		}
+		if len(fields) != len(index.Header) {
+			return nil, fmt.Errorf("Line %d: Expected %d columns, found %d", line_num, len(index.Header), len(fields))
+		}
+		row := cliTableRow{values: make(map[string]*regexp.Regexp)}
+		for i, column := range index.Header {
+			value := fields[i]
+			if column == "Template" {
+				row.templates = strings.Split(value, ":")
+				continue
+			}
+			if value == "" {
+				continue
+			}
+			if column == "Command" {
+				value = COMPLETION_RE.ReplaceAllStringFunc(value, completion)
+			}
+			regex, err := regexp.Compile("^(?:" + value + ")")
+			if err != nil {
+				return nil, fmt.Errorf("Line %d: Invalid regular expression '%s' in column '%s'. Error: '%s'", line_num, value, column, err.Error())
+			}
+			row.values[column] = regex
+		}
+		index.rows = append(index.rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if index.Header == nil {
+		return nil, fmt.Errorf("Index has no header")
+	}
+	return index, nil
+}
+
+// completion turns '[[xyz]]' into '(x(y(z)?)?)?'
+func completion(match string) string {
+	word := []rune(match[2 : len(match)-2])
+	var sb strings.Builder
+	for _, ch := range word {
+		sb.WriteString("(" + regexp.QuoteMeta(string(ch)))
+	}
+	sb.WriteString(strings.Repeat(")?", len(word)))
+	return sb.String()
+}
+
+// Lookup returns the templates of the first row of the index that matches the attributes.
+// Attributes that are not columns of the index and empty columns of the row are ignored.
+// Returns false if no row matches.
+func (index *CliTableIndex) Lookup(attributes map[string]string) ([]string, bool) {
+	for _, row := range index.rows {
+		matched := true
+		for name, value := range attributes {
+			if regex, exists := row.values[name]; exists && !regex.MatchString(value) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return append([]string{}, row.templates...), true
+		}
+	}
+	return nil, false
+}
+
+// CliTable parses the output of commands with the templates found through a CliTableIndex.
+// Templates are parsed once and shared. CliTable can be used from multiple goroutines.
+type CliTable struct {
+	Index *CliTableIndex
+	fsys  fs.FS
+	dir   string
+	mutex sync.Mutex
+	fsms  map[string]TextFSM
+}
+
+// NewCliTable reads the index file from fsys. Templates are read from the directory of the index file (See ParseFS).
+func NewCliTable(fsys fs.FS, index_file string) (*CliTable, error) {
+	file, err := fsys.Open(index_file)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	index, err := ParseCliTableIndex(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", index_file, err.Error())
+	}
+	return &CliTable{Index: index, fsys: fsys, dir: path.Dir(index_file), fsms: make(map[string]TextFSM)}, nil
+}
+
+// template returns the parsed template with the given file name.
+func (c *CliTable) template(name string) (TextFSM, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if fsm, exists := c.fsms[name]; exists {
+		return fsm, nil
+	}
+	fsm := TextFSM{}
+	if err := fsm.ParseFS(c.fsys, path.Join(c.dir, name)); err != nil {
+		return TextFSM{}, err
+	}
+	c.fsms[name] = fsm
+	return fsm, nil
+}
+
+// ParseCmd parses the output of a command with the templates of the first row of the index that matches the attributes.
+// ex. attributes: {"Platform": "cisco_ios", "Command": "show version"}
+//
+// When the row has more than one template, the records of the first template are extended with the
+// values of the other templates. The records are joined by the values of the Key Values of the first template
+// (or by position, if there are none). Like CliTable of Python.
+//     Returns:
+//       Records and error if no template is found or if there is any error in parsing.
+func (c *CliTable) ParseCmd(text string, attributes map[string]string) ([]map[string]interface{}, error) {
+	names, found := c.Index.Lookup(attributes)
+	if !found {
+		return nil, fmt.Errorf("No template found for attributes %v", attributes)
+	}
+	var records []map[string]interface{}
+	var base TextFSM
+	var keys []string
+	for i, name := range names {
+		fsm, err := c.template(name)
+		if err != nil {
+			return nil, err
+		}
+		out := ParserOutput{}
+		if err := out.ParseTextString(text, fsm, true); err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		if i == 0 {
+			records = out.Dict
+			base = fsm
+			for _, value := range fsm.value_names {
+				if FindIndex(fsm.Values[value].Options, "Key") >= 0 {
+					keys = append(keys, value)
+				}
+			}
+			continue
+		}
+		// Values of this template that are not in the first template. With their empty values.
+		columns := make(map[string]interface{})
+		for _, value := range fsm.value_names {
+			if _, exists := base.Values[value]; !exists {
+				valobj := fsm.Values[value]
+				columns[value] = valobj.getFinalValueInternal(nil)
+			}
+		}
+		extendRecords(records, out.Dict, keys, columns)
+	}
+	return records, nil
+}
+
+// extendRecords adds the columns to the records. The values are taken from the first extra record that has
+// the same values of the keys. Records are paired by position if there are no keys.
+// Records without a matching extra record get the empty values of the columns.
+func extendRecords(records []map[string]interface{}, extra []map[string]interface{}, keys []string, columns map[string]interface{}) {
+	for i, record := range records {
+		var match map[string]interface{}
+		if len(keys) == 0 {
+			if i < len(extra) {
+				match = extra[i]
+			}
+		} else {
+			for _, other := range extra {
+				same := true
+				for _, key := range keys {
+					if !reflect.DeepEqual(record[key], other[key]) {
+						same = false
+						break
+					}
+				}
+				if same {
+					match = other
+					break
+				}
+			}
+		}
+		for name, empty := range columns {
+			if value, exists := match[name]; exists {
+				record[name] = value
+			} else {
+				record[name] = empty
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package gotextfsm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const clitableIndex = `# Index of the templates
+Template, Hostname, Platform, Command
+
+cisco_ios_show_version.textfsm, , cisco_ios, sh[[ow]] ver[[sion]]
+cisco_ios_show_interfaces.textfsm:cisco_ios_show_interfaces_desc.textfsm, , cisco_ios, sh[[ow]] int[[erfaces]]
+`
+
+var clitableFS = fstest.MapFS{
+	"templates/index": &fstest.MapFile{Data: []byte(clitableIndex)},
+	"templates/cisco_ios_show_version.textfsm": &fstest.MapFile{Data: []byte(`Value Version (\S+)
+Value Hostname (\S+)
+
+Start
+  ^.*Version ${Version},
+  ^${Hostname} uptime -> Record
+`)},
+	"templates/cisco_ios_show_interfaces.textfsm": &fstest.MapFile{Data: []byte(`Value Key Interface (\S+)
+Value Status (up|down)
+
+Start
+  ^${Interface} is ${Status} -> Record
+`)},
+	"templates/cisco_ios_show_interfaces_desc.textfsm": &fstest.MapFile{Data: []byte(`Value Interface (\S+)
+Value Description (.*)
+
+Start
+  ^${Interface} desc ${Description} -> Record
+`)},
+}
+
+func TestCliTableIndex(t *testing.T) {
+	index, err := ParseCliTableIndex(strings.NewReader(clitableIndex))
+	if err != nil {
+		t.Fatalf("Index parsing failed with error '%s'", err)
+	}
+	cases := []struct {
+		platform  string
+		command   string
+		templates []string
+	}{
+		{"cisco_ios", "show version", []string{"cisco_ios_show_version.textfsm"}},
+		{"cisco_ios", "sh ver", []string{"cisco_ios_show_version.textfsm"}},
+		{"cisco_ios", "show vers", []string{"cisco_ios_show_version.textfsm"}},
+		{"cisco_ios", "sh int", []string{"cisco_ios_show_interfaces.textfsm", "cisco_ios_show_interfaces_desc.textfsm"}},
+		{"cisco_ios", "s ver", nil},
+		{"cisco_ios", "show clock", nil},
+		{"juniper_junos", "show version", nil},
+	}
+	for _, tc := range cases {
+		templates, found := index.Lookup(map[string]string{"Platform": tc.platform, "Command": tc.command})
+		if found != (tc.templates != nil) || !reflect.DeepEqual(templates, tc.templates) {
+			t.Errorf("'%s %s' failed. Templates dont match (%v, %v)", tc.platform, tc.command, tc.templates, templates)
+		}
+	}
+}
+
+func TestCliTableIndexErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		index string
+		err   string
+	}{
+		{"No Template column", "Platform, Command\ncisco_ios, show version\n", "Line 1: Index header must have 'Template' column"},
+		{"Missing column", "Template, Platform, Command\nfoo.textfsm, cisco_ios\n", "Line 2: Expected 3 columns, found 2"},
+		{"Invalid regex", "Template, Command\nfoo.textfsm, show (version\n", "Line 2: Invalid regular expression"},
+		{"Empty", "# Only comments\n", "Index has no header"},
+	}
+	for _, tc := range cases {
+		_, err := ParseCliTableIndex(strings.NewReader(tc.index))
+		if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+			t.Errorf("'%s' failed. Errors dont match ('%s', '%v')", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestCliTableParseCmd(t *testing.T) {
+	table, err := NewCliTable(clitableFS, "templates/index")
+	if err != nil {
+		t.Fatalf("CliTable creation failed with error '%s'", err)
+	}
+	data := "Gi0/1 is up\nGi0/2 is down\nGi0/2 desc Uplink\n"
+	dict, err := table.ParseCmd(data, map[string]string{"Platform": "cisco_ios", "Command": "show interfaces"})
+	if err != nil {
+		t.Fatalf("ParseCmd failed with error '%s'", err)
+	}
+	expected := []map[string]interface{}{
+		{"Interface": "Gi0/1", "Status": "up", "Description": ""},
+		{"Interface": "Gi0/2", "Status": "down", "Description": "Uplink"},
+	}
+	if !reflect.DeepEqual(dict, expected) {
+		t.Errorf("Records dont match (%v, %v)", expected, dict)
+	}
+	if _, err := table.ParseCmd(data, map[string]string{"Platform": "cisco_ios", "Command": "show clock"}); err == nil {
+		t.Errorf("Expected error for a command without template")
+	}
+}
+
+func TestSplitTranscript(t *testing.T) {
+	transcript := "Welcome banner\r\nrouter1#show version\r\nCisco IOS Software, Version 15.2(4)M3,\r\nrouter1 uptime is 2 weeks\r\n" +
+		"router1#  show interfaces  \r\nGi0/1 is up\r\n  Description: uplink#\r\nword#\r\nlevel>\r\n" +
+		"router1(config-if)# shutdown\r\nrouter1(config-if)#\r\nignored\r\nuser@host:~/dir> show route\r\n10.0.0.0/8\r\nrouter2#\r\n\r\n"
+	expected := []TranscriptSection{
+		{Line: 2, Prompt: "router1#", Command: "show version", Output: "Cisco IOS Software, Version 15.2(4)M3,\nrouter1 uptime is 2 weeks\n"},
+		{Line: 5, Prompt: "router1#", Command: "show interfaces", Output: "Gi0/1 is up\n  Description: uplink#\nword#\nlevel>\n"},
+		{Line: 10, Prompt: "router1(config-if)#", Command: "shutdown", Output: ""},
+		{Line: 13, Prompt: "user@host:~/dir>", Command: "show route", Output: "10.0.0.0/8\n"},
+	}
+	sections := SplitTranscript(transcript, nil)
+	if !reflect.DeepEqual(sections, expected) {
+		t.Errorf("Sections dont match (%v, %v)", expected, sections)
+	}
+	// A trailing prompt that differs from the prompt of the section is not output.
+	sections = SplitTranscript("router1#show clock\n10:00:00 UTC\nrouter1(config)#", nil)
+	expected = []TranscriptSection{{Line: 1, Prompt: "router1#", Command: "show clock", Output: "10:00:00 UTC\n"}}
+	if !reflect.DeepEqual(sections, expected) {
+		t.Errorf("Sections dont match (%v, %v)", expected, sections)
+	}
+}
+
+func TestCliTableParseTranscript(t *testing.T) {
+	table, err := NewCliTable(clitableFS, "templates/index")
+	if err != nil {
+		t.Fatalf("CliTable creation failed with error '%s'", err)
+	}
+	transcript := "<sw1>show version\nCisco IOS Software, Version 15.2(4)M3,\nsw1 uptime is 2 weeks\n" +
+		"<sw1>show clock\n10:00:00 UTC\n<sw1>sh int\nGi0/1 is up\n<sw1>"
+	results := table.ParseTranscript(transcript, nil, map[string]string{"Platform": "cisco_ios"})
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results. Got %d", len(results))
+	}
+	expected := [][]map[string]interface{}{
+		{{"Version": "15.2(4)M3", "Hostname": "sw1"}},
+		nil,
+		{{"Interface": "Gi0/1", "Status": "up", "Description": ""}},
+	}
+	for i, result := range results {
+		if (result.Err != nil) != (expected[i] == nil) {
+			t.Errorf("'%s' failed. Unexpected error '%v'", result.Section.Command, result.Err)
+		}
+		if !reflect.DeepEqual(result.Dict, expected[i]) {
+			t.Errorf("'%s' failed. Records dont match (%v, %v)", result.Section.Command, expected[i], result.Dict)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Command gotextfsm runs tools for TextFSM templates.
+//
+// Usage:
+//
+//	gotextfsm test [-v] [-lowercase-keys] [-update] <dir>
+//	gotextfsm explain [-state <state>] [-root <dir>] <template> [<line>]
+//
+// test discovers the template tests in <dir> (See gotextfsm.DiscoverTemplateTests), runs them and
+// prints the differences between the expected and the parsed records. Exits with status 1 if any test fails.
+// With -update, the expected records of the failed tests are rewritten with the parsed records instead.
+//
+// explain tells how far each rule of the state (Start by default) got in matching the line
+// (See gotextfsm.ExplainLine). Each line of the standard input is explained, if no line is given.
+// The template is read with gotextfsm.ParseFS from the file system rooted at -root (the working directory by default).
+// So that its includes (and the template it extends) can be anywhere under the root. ex. '../lib/common.textfsm'
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirikothe/gotextfsm"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage:\n")
+	fmt.Fprintf(os.Stderr, "  gotextfsm test [-v] [-lowercase-keys] [-update] <dir>\n")
+	fmt.Fprintf(os.Stderr, "  gotextfsm explain [-state <state>] [-root <dir>] <template> [<line>]\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "test":
+		os.Exit(runTest(os.Args[2:]))
+	case "explain":
+		os.Exit(runExplain(os.Args[2:]))
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func runTest(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	verbose := flags.Bool("v", false, "List the tests that passed as well")
+	lowercase := flags.Bool("lowercase-keys", false, "Compare the names of the values in lower case (as in ntc-templates)")
+	update := flags.Bool("update", false, "Rewrite the expected records of the failed tests with the parsed records")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+		return 2
+	}
+	options := gotextfsm.TemplateTestOptions{LowercaseKeys: *lowercase}
+	var results []gotextfsm.TemplateTestResult
+	var err error
+	if *update {
+		results, err = gotextfsm.UpdateTemplateTests(flags.Arg(0), options)
+	} else {
+		results, err = gotextfsm.RunTemplateTests(os.DirFS(flags.Arg(0)), options)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		return 2
+	}
+	if !gotextfsm.WriteTemplateTestReport(os.Stdout, results, *verbose) {
+		return 1
+	}
+	return 0
+}
+
+func runExplain(args []string) int {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	state := flags.String("state", "Start", "State, the rules of which are explained")
+	root := flags.String("root", ".", "Directory under which the template and the files it includes are read")
+	flags.Parse(args)
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		usage()
+		return 2
+	}
+	name, err := templatePath(*root, flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		return 2
+	}
+	fsm := gotextfsm.TextFSM{}
+	if err := fsm.ParseFS(os.DirFS(*root), name); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		return 2
+	}
+	if flags.NArg() == 2 {
+		return explainLine(fsm, *state, flags.Arg(1))
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fmt.Printf("%s\n", scanner.Text())
+		if ret := explainLine(fsm, *state, scanner.Text()); ret != 0 {
+			return ret
+		}
+	}
+	return 0
+}
+
+// templatePath returns the path of the template relative to the root, as the name of a file of os.DirFS(root).
+func templatePath(root string, template string) (string, error) {
+	abs_root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	abs_template, err := filepath.Abs(template)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(abs_root, abs_template)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Template %s is not under the root %s", template, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func explainLine(fsm gotextfsm.TextFSM, state string, line string) int {
+	explanations, err := fsm.ExplainLine(state, line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		return 2
+	}
+	for _, explanation := range explanations {
+		fmt.Printf("  %s\n", explanation)
+	}
+	return 0
+}
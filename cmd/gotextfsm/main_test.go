@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExplainInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"lib/common.textfsm":        "Macro IPV4 (?:\\d{1,3}\\.){3}\\d{1,3}\nValue Required Interface (\\S+)\n",
+		"templates/show_ip.textfsm": "Include ../lib/common.textfsm\nValue Address (${IPV4})\n\nStart\n  ^interface ${Interface}\n  ^ ip address ${Address} -> Record\n",
+		"base/interfaces.textfsm":   "Value Interface (\\S+)\n\nStart\n  # @label interface\n  ^interface ${Interface} -> Record\n",
+		"vendor/interfaces.textfsm": "Extends ../base/interfaces.textfsm\nValue Mtu (\\d+)\n\n@after interface\n  ^\\s+MTU ${Mtu}\n",
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	cases := []struct {
+		name string
+		args []string
+		ret  int
+	}{
+		{name: "Include from the working directory", args: []string{"templates/show_ip.textfsm", "interface Gi0/1"}, ret: 0},
+		{name: "Extends from the working directory", args: []string{"vendor/interfaces.textfsm", "interface Gi0/1"}, ret: 0},
+		{name: "Include from the root", args: []string{"-root", dir, filepath.Join(dir, "templates", "show_ip.textfsm"), "interface Gi0/1"}, ret: 0},
+		{name: "Include out of the root", args: []string{"-root", "templates", "templates/show_ip.textfsm", "interface Gi0/1"}, ret: 2},
+		{name: "Template out of the root", args: []string{"-root", "lib", "templates/show_ip.textfsm", "interface Gi0/1"}, ret: 2},
+	}
+	for _, tc := range cases {
+		if ret := runExplain(tc.args); ret != tc.ret {
+			t.Errorf("'%s' failed. Exit status dont match (%d, %d)", tc.name, tc.ret, ret)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package gotextfsm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diagnostic is an 'Error' action of the template (or a line that matched no rule in a strict state),
+// hit while parsing in Lenient mode. RuleLine is 0 for the latter.
+type Diagnostic struct {
+	State     string `json:"state"`      // State of FSM in which the rule is
+	RuleLine  int    `json:"rule_line"`  // Line number of the rule in the template
+	InputLine int    `json:"input_line"` // Line number of the input
+	Input     string `json:"input"`      // The input line that matched the rule
+	Message   string `json:"message"`    // Message given to the 'Error' action. Default message if none given.
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d Line: %s. State: %s. Rule Line: %d. Input Line: %s", d.InputLine, d.Message, d.State, d.RuleLine, d.Input)
+}
+
+// newDiagnostic returns the Diagnostic for the 'Error' action of rule, matched by the line.
+func (t *ParserOutput) newDiagnostic(rule TextFSMRule, line string) Diagnostic {
+	message := "State Error raised"
+	if rule.NewState != "" {
+		message = strings.Trim(rule.NewState, `"`)
+	}
+	return Diagnostic{State: t.cur_state_name, RuleLine: rule.LineNum, InputLine: t.line_num, Input: line, Message: message}
+}
+
+// UnmatchedLine is an input line that matched no rule of the state FSM was in.
+type UnmatchedLine struct {
+	Line  int    `json:"line"`  // Line number of the input
+	State string `json:"state"` // State of FSM when the line was seen
+	Input string `json:"input"` // The input line
+}
+
+// noMatch handles the line that matched no rule of the current state.
+// The line is collected if CollectUnmatched is set. Returns error if the current state is one of StrictStates.
+// In Lenient mode, such lines are added to Diagnostics instead.
+func (t *ParserOutput) noMatch(line string) error {
+	if t.CollectUnmatched {
+		t.Unmatched = append(t.Unmatched, UnmatchedLine{Line: t.line_num, State: t.cur_state_name, Input: line})
+	}
+	if FindIndex(t.StrictStates, t.cur_state_name) < 0 {
+		return nil
+	}
+	if t.Lenient {
+		t.Diagnostics = append(t.Diagnostics, Diagnostic{State: t.cur_state_name, InputLine: t.line_num, Input: line, Message: "No rule matched"})
+		return nil
+	}
+	return fmt.Errorf("%d Line: No rule matched in strict state %s. Input Line: %s", t.line_num, t.cur_state_name, line)
+}
@@ -0,0 +1,140 @@
+package gotextfsm
+
+import (
+	"reflect"
+	"testing"
+)
+
+const diagnosticsTemplate = `Value Required Interface (\S+)
+Value Status (up|down)
+
+Start
+  ^Interface ${Interface} is ${Status} -> Record
+  ^Interface ${Interface} is unknown -> Error "Unknown status"
+  ^Bogus -> Error
+`
+
+func TestLenientParsing(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString(diagnosticsTemplate); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	data := "Interface eth0 is up\nInterface eth1 is unknown\nBogus line\nInterface eth2 is down\n"
+
+	strict := ParserOutput{}
+	if err := strict.ParseTextString(data, fsm, true); err == nil {
+		t.Errorf("Expected error when not Lenient")
+	}
+
+	out := ParserOutput{Lenient: true}
+	if err := out.ParseTextString(data, fsm, true); err != nil {
+		t.Fatalf("Expected no error when Lenient. Got '%s'", err)
+	}
+	expected := []map[string]interface{}{
+		{"Interface": "eth0", "Status": "up"},
+		{"Interface": "eth2", "Status": "down"},
+	}
+	if !reflect.DeepEqual(out.Dict, expected) {
+		t.Errorf("Records dont match (%v, %v)", expected, out.Dict)
+	}
+	diagnostics := []Diagnostic{
+		{State: "Start", RuleLine: 6, InputLine: 2, Input: "Interface eth1 is unknown", Message: "Unknown status"},
+		{State: "Start", RuleLine: 7, InputLine: 3, Input: "Bogus line", Message: "State Error raised"},
+	}
+	if !reflect.DeepEqual(out.Diagnostics, diagnostics) {
+		t.Errorf("Diagnostics dont match (%v, %v)", diagnostics, out.Diagnostics)
+	}
+	if s := out.Diagnostics[0].String(); s != "2 Line: Unknown status. State: Start. Rule Line: 6. Input Line: Interface eth1 is unknown" {
+		t.Errorf("Unexpected string '%s'", s)
+	}
+
+	// Diagnostics are part of the snapshot
+	data1, err := out.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed with error '%s'", err)
+	}
+	restored := ParserOutput{}
+	if err := restored.Restore(data1, fsm); err != nil {
+		t.Fatalf("Restore failed with error '%s'", err)
+	}
+	if !reflect.DeepEqual(restored.Diagnostics, diagnostics) {
+		t.Errorf("Restored diagnostics dont match (%v, %v)", diagnostics, restored.Diagnostics)
+	}
+
+	out.Reset(fsm)
+	if out.Diagnostics != nil || !out.Lenient {
+		t.Errorf("Reset should clear Diagnostics and keep Lenient")
+	}
+}
+
+const unmatchedTemplate = `Value Interface (\S+)
+Value Status (up|down)
+
+Start
+  ^Interfaces -> Interfaces
+
+Interfaces
+  ^Interface ${Interface} is ${Status} -> Record
+  ^\s*$$
+`
+
+func TestUnmatchedLines(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString(unmatchedTemplate); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	data := "Banner\nInterfaces\nInterface eth0 is up\nInterface eth1 is administratively down\n\nInterface eth2 is down\n"
+	expected := []map[string]interface{}{
+		{"Interface": "eth0", "Status": "up"},
+		{"Interface": "eth2", "Status": "down"},
+	}
+
+	out := ParserOutput{}
+	if err := out.ParseTextString(data, fsm, true); err != nil || out.Unmatched != nil {
+		t.Errorf("Unmatched lines should not be collected by default. Got (%v, %v)", err, out.Unmatched)
+	}
+
+	out = ParserOutput{CollectUnmatched: true}
+	if err := out.ParseTextString(data, fsm, true); err != nil {
+		t.Fatalf("Expected no error. Got '%s'", err)
+	}
+	unmatched := []UnmatchedLine{
+		{Line: 1, State: "Start", Input: "Banner"},
+		{Line: 4, State: "Interfaces", Input: "Interface eth1 is administratively down"},
+	}
+	if !reflect.DeepEqual(out.Unmatched, unmatched) {
+		t.Errorf("Unmatched lines dont match (%v, %v)", unmatched, out.Unmatched)
+	}
+	if !reflect.DeepEqual(out.Dict, expected) {
+		t.Errorf("Records dont match (%v, %v)", expected, out.Dict)
+	}
+
+	// Same with combined matching of rules
+	combined := fsm
+	combined.EnableCombinedMatching()
+	out = ParserOutput{CollectUnmatched: true}
+	if err := out.ParseTextString(data, combined, true); err != nil || !reflect.DeepEqual(out.Unmatched, unmatched) {
+		t.Errorf("Unmatched lines dont match with combined matching (%v, %v). Error: %v", unmatched, out.Unmatched, err)
+	}
+
+	// Lines that match no rule in 'Start' are fine. But not in 'Interfaces'
+	out = ParserOutput{StrictStates: []string{"Interfaces"}}
+	err := out.ParseTextString(data, fsm, true)
+	if err == nil || err.Error() != "4 Line: No rule matched in strict state Interfaces. Input Line: Interface eth1 is administratively down" {
+		t.Errorf("Expected error for unmatched line in strict state. Got '%v'", err)
+	}
+
+	out = ParserOutput{StrictStates: []string{"Interfaces"}, Lenient: true}
+	if err := out.ParseTextString(data, fsm, true); err != nil {
+		t.Fatalf("Expected no error when Lenient. Got '%s'", err)
+	}
+	diagnostics := []Diagnostic{
+		{State: "Interfaces", InputLine: 4, Input: "Interface eth1 is administratively down", Message: "No rule matched"},
+	}
+	if !reflect.DeepEqual(out.Diagnostics, diagnostics) {
+		t.Errorf("Diagnostics dont match (%v, %v)", diagnostics, out.Diagnostics)
+	}
+	if !reflect.DeepEqual(out.Dict, expected) {
+		t.Errorf("Records dont match (%v, %v)", expected, out.Dict)
+	}
+}
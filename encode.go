@@ -0,0 +1,434 @@
+package gotextfsm
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CSVOptions controls how values that are not plain strings are written by WriteCSV.
+//     ListSeparator: Joins the elements of List values. Default is ";".
+//     PairSeparator: Joins the 'group=value' pairs of values with nested match groups. Default is " ".
+//     FlattenMaps: Write each nested match group of a value in its own column named 'value.group'
+//                  instead of writing all the groups in one column as 'group=value' pairs.
+//                  For List values, each column holds the elements of that group joined by ListSeparator.
+//     NoHeader: Do not write the header line with the column names.
+type CSVOptions struct {
+	ListSeparator string
+	PairSeparator string
+	FlattenMaps   bool
+	NoHeader      bool
+}
+
+// recordField is a value of a record, together with the order of the keys if the value is a map.
+type recordField struct {
+	name  string
+	value interface{}
+	keys  []string
+}
+
+// orderedFields returns the values of the record in the order of declaration of the Values in the template.
+// Records of the levels below (See 'Level=' option) follow in the order of the levels.
+// Keys of the record that are not Values or levels of the template (if any) follow in sorted order.
+func orderedFields(fsm TextFSM, record map[string]interface{}) []recordField {
+	fields := make([]recordField, 0, len(record))
+	for _, name := range fsm.value_names {
+		if val, exists := record[name]; exists {
+			fields = append(fields, recordField{name: name, value: val, keys: fsm.Values[name].group_names})
+		}
+	}
+	for _, path := range fsm.level_paths {
+		name := levelName(path)
+		if val, exists := record[name]; exists {
+			fields = append(fields, recordField{name: name, value: val})
+		}
+	}
+	extra := make([]string, 0)
+	for name := range record {
+		_, is_value := fsm.Values[name]
+		_, is_level := fsm.levels[name]
+		if !is_value && !is_level {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		fields = append(fields, recordField{name: name, value: record[name]})
+	}
+	return fields
+}
+
+// mapKeys returns the keys of the map, in the given order first and the rest in sorted order.
+func mapKeys(m map[string]string, order []string) []string {
+	keys := make([]string, 0, len(m))
+	for _, key := range order {
+		if _, exists := m[key]; exists {
+			keys = append(keys, key)
+		}
+	}
+	extra := make([]string, 0)
+	for key := range m {
+		if FindIndex(order, key) < 0 {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	return append(keys, extra...)
+}
+
+// WriteJSON writes the records as an indented JSON array to w, one record at a time.
+// Values of each record (and nested match groups of each value) are written in the order of their declaration in the template.
+// List values are written as arrays and values with nested match groups as objects.
+// Records of the levels below (See 'Level=' option) are written as arrays of objects.
+//     Args:
+//       w: (io.Writer), Destination of the JSON.
+//       fsm: (TextFSM), Template used to parse the records.
+//       records: ([]map[string]interface{}), Records to write. Usually ParserOutput.Dict
+//     Returns:
+//       error if there is any error while writing.
+func WriteJSON(w io.Writer, fsm TextFSM, records []map[string]interface{}) (err error) {
+	defer recoverInternalError("WriteJSON", &err)
+	bw := bufio.NewWriter(w)
+	if len(records) == 0 {
+		bw.WriteString("[]\n")
+		return bw.Flush()
+	}
+	bw.WriteString("[\n")
+	for i, record := range records {
+		bw.WriteString("  ")
+		if err := writeJSONRecord(bw, fsm, record, "  "); err != nil {
+			return err
+		}
+		if i < len(records)-1 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n")
+		// Stream each record as it is written.
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	bw.WriteString("]\n")
+	return bw.Flush()
+}
+
+func writeJSONString(w *bufio.Writer, str string) {
+	data, _ := json.Marshal(str)
+	w.Write(data)
+}
+
+// writeJSONRecord writes the record as an object. indent is the indentation of the line the object starts on.
+func writeJSONRecord(w *bufio.Writer, fsm TextFSM, record map[string]interface{}, indent string) error {
+	w.WriteString("{")
+	fields := orderedFields(fsm, record)
+	for j, field := range fields {
+		if j > 0 {
+			w.WriteString(",")
+		}
+		w.WriteString("\n" + indent + "  ")
+		writeJSONString(w, field.name)
+		w.WriteString(": ")
+		if err := writeJSONValue(w, fsm, field.value, field.keys, indent+"  "); err != nil {
+			return err
+		}
+	}
+	if len(fields) > 0 {
+		w.WriteString("\n" + indent)
+	}
+	w.WriteString("}")
+	return nil
+}
+
+func writeJSONValue(w *bufio.Writer, fsm TextFSM, val interface{}, keys []string, indent string) error {
+	switch v := val.(type) {
+	case nil:
+		w.WriteString("null")
+	case string:
+		writeJSONString(w, v)
+	case []string:
+		if len(v) == 0 {
+			w.WriteString("[]")
+			return nil
+		}
+		w.WriteString("[")
+		for i, elem := range v {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n" + indent + "  ")
+			writeJSONString(w, elem)
+		}
+		w.WriteString("\n" + indent + "]")
+	case map[string]string:
+		if len(v) == 0 {
+			w.WriteString("{}")
+			return nil
+		}
+		w.WriteString("{")
+		for i, key := range mapKeys(v, keys) {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n" + indent + "  ")
+			writeJSONString(w, key)
+			w.WriteString(": ")
+			writeJSONString(w, v[key])
+		}
+		w.WriteString("\n" + indent + "}")
+	case []map[string]string:
+		if len(v) == 0 {
+			w.WriteString("[]")
+			return nil
+		}
+		w.WriteString("[")
+		for i, elem := range v {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n" + indent + "  ")
+			if err := writeJSONValue(w, fsm, elem, keys, indent+"  "); err != nil {
+				return err
+			}
+		}
+		w.WriteString("\n" + indent + "]")
+	case []map[string]interface{}:
+		if len(v) == 0 {
+			w.WriteString("[]")
+			return nil
+		}
+		w.WriteString("[")
+		for i, elem := range v {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n" + indent + "  ")
+			if err := writeJSONRecord(w, fsm, elem, indent+"  "); err != nil {
+				return err
+			}
+		}
+		w.WriteString("\n" + indent + "]")
+	default:
+		return fmt.Errorf("Unknown data type %T", val)
+	}
+	return nil
+}
+
+// WriteYAML writes the records as a YAML sequence of mappings to w, one record at a time.
+// Order of values and representation of List and nested match group values is same as WriteJSON.
+// All the strings are double quoted so that they are never interpreted as other types.
+// See WriteJSON for the arguments.
+func WriteYAML(w io.Writer, fsm TextFSM, records []map[string]interface{}) (err error) {
+	defer recoverInternalError("WriteYAML", &err)
+	bw := bufio.NewWriter(w)
+	if len(records) == 0 {
+		bw.WriteString("[]\n")
+		return bw.Flush()
+	}
+	for _, record := range records {
+		if err := writeYAMLRecord(bw, fsm, record, ""); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+var yamlPlainKeyRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func yamlKey(key string) string {
+	if yamlPlainKeyRE.MatchString(key) {
+		return key
+	}
+	data, _ := json.Marshal(key)
+	return string(data)
+}
+
+// writeYAMLRecord writes the record as an item of a sequence. indent is the indentation of the '- '.
+func writeYAMLRecord(w *bufio.Writer, fsm TextFSM, record map[string]interface{}, indent string) error {
+	fields := orderedFields(fsm, record)
+	if len(fields) == 0 {
+		w.WriteString(indent + "- {}\n")
+	}
+	for j, field := range fields {
+		if j == 0 {
+			w.WriteString(indent + "- ")
+		} else {
+			w.WriteString(indent + "  ")
+		}
+		if err := writeYAMLField(w, fsm, field.name, field.value, field.keys, indent+"  "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeYAMLField writes 'name: value'. The name is expected to be already indented. indent is the indentation
+// of the name. Nested lines are indented further.
+func writeYAMLField(w *bufio.Writer, fsm TextFSM, name string, val interface{}, keys []string, indent string) error {
+	w.WriteString(yamlKey(name) + ":")
+	switch v := val.(type) {
+	case nil:
+		w.WriteString(" null\n")
+	case string:
+		w.WriteString(" ")
+		writeJSONString(w, v)
+		w.WriteString("\n")
+	case []string:
+		if len(v) == 0 {
+			w.WriteString(" []\n")
+			return nil
+		}
+		w.WriteString("\n")
+		for _, elem := range v {
+			w.WriteString(indent + "  - ")
+			writeJSONString(w, elem)
+			w.WriteString("\n")
+		}
+	case map[string]string:
+		if len(v) == 0 {
+			w.WriteString(" {}\n")
+			return nil
+		}
+		w.WriteString("\n")
+		for _, key := range mapKeys(v, keys) {
+			w.WriteString(indent + "  " + yamlKey(key) + ": ")
+			writeJSONString(w, v[key])
+			w.WriteString("\n")
+		}
+	case []map[string]string:
+		if len(v) == 0 {
+			w.WriteString(" []\n")
+			return nil
+		}
+		w.WriteString("\n")
+		for _, elem := range v {
+			if len(elem) == 0 {
+				w.WriteString(indent + "  - {}\n")
+				continue
+			}
+			for i, key := range mapKeys(elem, keys) {
+				if i == 0 {
+					w.WriteString(indent + "  - ")
+				} else {
+					w.WriteString(indent + "    ")
+				}
+				w.WriteString(yamlKey(key) + ": ")
+				writeJSONString(w, elem[key])
+				w.WriteString("\n")
+			}
+		}
+	case []map[string]interface{}:
+		if len(v) == 0 {
+			w.WriteString(" []\n")
+			return nil
+		}
+		w.WriteString("\n")
+		for _, elem := range v {
+			if err := writeYAMLRecord(w, fsm, elem, indent+"  "); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("Unknown data type %T", val)
+	}
+	return nil
+}
+
+// WriteCSV writes the records as CSV to w, one record per line, preceded by a header line.
+// Columns are the Values in the order of their declaration in the template.
+// Only the Values of the top level records are written. Records of the levels below (See 'Level=' option) are not.
+// See CSVOptions for how List values and values with nested match groups are written.
+// See WriteJSON for the other arguments.
+func WriteCSV(w io.Writer, fsm TextFSM, records []map[string]interface{}, options CSVOptions) (err error) {
+	defer recoverInternalError("WriteCSV", &err)
+	if options.ListSeparator == "" {
+		options.ListSeparator = ";"
+	}
+	if options.PairSeparator == "" {
+		options.PairSeparator = " "
+	}
+	type column struct {
+		name  string
+		value string
+		group string
+	}
+	columns := make([]column, 0)
+	for _, name := range fsm.levelValueNames("") {
+		groups := fsm.Values[name].group_names
+		if options.FlattenMaps && len(groups) > 0 {
+			for _, group := range groups {
+				columns = append(columns, column{name: name + "." + group, value: name, group: group})
+			}
+		} else {
+			columns = append(columns, column{name: name, value: name})
+		}
+	}
+	cw := csv.NewWriter(w)
+	if !options.NoHeader {
+		header := make([]string, 0, len(columns))
+		for _, col := range columns {
+			header = append(header, col.name)
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	for _, record := range records {
+		row := make([]string, 0, len(columns))
+		for _, col := range columns {
+			keys := fsm.Values[col.value].group_names
+			var cell string
+			switch v := record[col.value].(type) {
+			case nil:
+				cell = ""
+			case string:
+				cell = v
+			case []string:
+				cell = strings.Join(v, options.ListSeparator)
+			case map[string]string:
+				if col.group != "" {
+					cell = v[col.group]
+				} else {
+					cell = joinPairs(v, keys, options.PairSeparator)
+				}
+			case []map[string]string:
+				elems := make([]string, 0, len(v))
+				for _, elem := range v {
+					if col.group != "" {
+						elems = append(elems, elem[col.group])
+					} else {
+						elems = append(elems, joinPairs(elem, keys, options.PairSeparator))
+					}
+				}
+				cell = strings.Join(elems, options.ListSeparator)
+			default:
+				return fmt.Errorf("Unknown data type %T for %s", v, col.value)
+			}
+			row = append(row, cell)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func joinPairs(m map[string]string, keys []string, separator string) string {
+	pairs := make([]string, 0, len(m))
+	for _, key := range mapKeys(m, keys) {
+		pairs = append(pairs, key+"="+m[key])
+	}
+	return strings.Join(pairs, separator)
+}
@@ -0,0 +1,178 @@
+package gotextfsm
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var encodeTemplate = `Value device (\S+)
+Value List vlans (\d+)
+Value address ((?P<ip>[\d.]+)/(?P<mask>\d+))
+Value List peers ((?P<name>\w+):(?P<asn>\d+))
+Value description (.*)
+
+Start
+  ^Device: ${device}
+  ^vlan ${vlans}
+  ^address ${address}
+  ^peer ${peers}
+  ^description ${description}
+  ^end -> Record
+`
+
+var encodeInput = `Device: sw1
+vlan 10
+vlan 20
+address 10.0.0.1/24
+peer a:100
+peer b:200
+description Uplink, "core"
+end
+Device: sw2
+end
+`
+
+func parseEncodeInput(t *testing.T) (TextFSM, []map[string]interface{}) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString(encodeTemplate); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	out := ParserOutput{}
+	if err := out.ParseTextString(encodeInput, fsm, true); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	return fsm, out.Dict
+}
+
+func TestWriteJSON(t *testing.T) {
+	fsm, dict := parseEncodeInput(t)
+	var sb strings.Builder
+	if err := WriteJSON(&sb, fsm, dict); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	expected := `[
+  {
+    "device": "sw1",
+    "vlans": [
+      "10",
+      "20"
+    ],
+    "address": {
+      "ip": "10.0.0.1",
+      "mask": "24"
+    },
+    "peers": [
+      {
+        "name": "a",
+        "asn": "100"
+      },
+      {
+        "name": "b",
+        "asn": "200"
+      }
+    ],
+    "description": "Uplink, \"core\""
+  },
+  {
+    "device": "sw2",
+    "vlans": [],
+    "address": {},
+    "peers": [],
+    "description": ""
+  }
+]
+`
+	if sb.String() != expected {
+		t.Errorf("JSON dont match. Got\n%s", sb.String())
+	}
+	// The output must be valid JSON with the same content as the records.
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal([]byte(sb.String()), &decoded); err != nil {
+		t.Fatalf("Invalid JSON '%s'", err)
+	}
+	direct, _ := json.Marshal(dict)
+	var expdecoded []map[string]interface{}
+	json.Unmarshal(direct, &expdecoded)
+	if !reflect.DeepEqual(decoded, expdecoded) {
+		t.Errorf("JSON content dont match. Got %v", decoded)
+	}
+	sb.Reset()
+	if err := WriteJSON(&sb, fsm, nil); err != nil || sb.String() != "[]\n" {
+		t.Errorf("Expected empty array. Got '%s', %v", sb.String(), err)
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	fsm, dict := parseEncodeInput(t)
+	var sb strings.Builder
+	if err := WriteYAML(&sb, fsm, dict); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	expected := `- device: "sw1"
+  vlans:
+    - "10"
+    - "20"
+  address:
+    ip: "10.0.0.1"
+    mask: "24"
+  peers:
+    - name: "a"
+      asn: "100"
+    - name: "b"
+      asn: "200"
+  description: "Uplink, \"core\""
+- device: "sw2"
+  vlans: []
+  address: {}
+  peers: []
+  description: ""
+`
+	if sb.String() != expected {
+		t.Errorf("YAML dont match. Got\n%s", sb.String())
+	}
+	sb.Reset()
+	if err := WriteYAML(&sb, fsm, nil); err != nil || sb.String() != "[]\n" {
+		t.Errorf("Expected empty sequence. Got '%s', %v", sb.String(), err)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	fsm, dict := parseEncodeInput(t)
+	testcases := []struct {
+		options  CSVOptions
+		expected string
+	}{
+		{
+			options: CSVOptions{},
+			expected: `device,vlans,address,peers,description
+sw1,10;20,ip=10.0.0.1 mask=24,name=a asn=100;name=b asn=200,"Uplink, ""core"""
+sw2,,,,
+`,
+		},
+		{
+			options: CSVOptions{ListSeparator: "|", PairSeparator: ",", FlattenMaps: true, NoHeader: false},
+			expected: `device,vlans,address.ip,address.mask,peers.name,peers.asn,description
+sw1,10|20,10.0.0.1,24,a|b,100|200,"Uplink, ""core"""
+sw2,,,,,,
+`,
+		},
+		{
+			options: CSVOptions{ListSeparator: "|", PairSeparator: ",", NoHeader: true},
+			expected: `sw1,10|20,"ip=10.0.0.1,mask=24","name=a,asn=100|name=b,asn=200","Uplink, ""core"""
+sw2,,,,
+`,
+		},
+	}
+	for i, tc := range testcases {
+		var sb strings.Builder
+		if err := WriteCSV(&sb, fsm, dict, tc.options); err != nil {
+			t.Errorf("Test case %d failed. Expected no error. But found error '%s'", i, err)
+			continue
+		}
+		if sb.String() != tc.expected {
+			t.Errorf("Test case %d failed. CSV dont match. Got\n%s", i, sb.String())
+		}
+	}
+}
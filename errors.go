@@ -0,0 +1,64 @@
+package gotextfsm
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// InternalError is returned by the public API instead of crashing the process, when an unexpected panic
+// happens while parsing a template or text, or while writing the results.
+// Such a panic is a bug in gotextfsm (or a TextFSM that is not built by parsing a template) and is worth reporting
+// with the details held here.
+type InternalError struct {
+	Op    string      // Name of the API that failed. ex: "ParseText"
+	State string      // Current state of FSM, if the failure happened while parsing text
+	Line  int         // Line number of the template or of the text being parsed. 0 if not known.
+	Cause interface{} // Value passed to panic
+	Stack []byte      // Stack trace of the panic
+}
+
+func (e *InternalError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Internal error in %s: %v", e.Op, e.Cause))
+	if e.State != "" {
+		sb.WriteString(fmt.Sprintf(". State: %s", e.State))
+	}
+	if e.Line > 0 {
+		sb.WriteString(fmt.Sprintf(". Line: %d", e.Line))
+	}
+	return sb.String()
+}
+
+// Unwrap returns the cause of the panic, if it is an error. ex: runtime.Error
+func (e *InternalError) Unwrap() error {
+	if err, ok := e.Cause.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// recoverInternalError converts a panic into an InternalError stored in *err.
+// Must be deferred directly by the public API, with a named error result.
+// ex: defer recoverInternalError("WriteJSON", &err)
+func recoverInternalError(op string, err *error) {
+	if r := recover(); r != nil {
+		*err = &InternalError{Op: op, Cause: r, Stack: debug.Stack()}
+	}
+}
+
+// recoverInternalError is same as the function recoverInternalError. In addition, the state and line number of parsing
+// are added to the error.
+func (t *ParserOutput) recoverInternalError(op string, err *error) {
+	if r := recover(); r != nil {
+		*err = &InternalError{Op: op, State: t.cur_state_name, Line: t.line_num, Cause: r, Stack: debug.Stack()}
+	}
+}
+
+// recoverInternalError is same as the function recoverInternalError. In addition, the line number of the template
+// is added to the error.
+func (t *TextFSM) recoverInternalError(op string, err *error) {
+	if r := recover(); r != nil {
+		*err = &InternalError{Op: op, Line: t.line_num, Cause: r, Stack: debug.Stack()}
+	}
+}
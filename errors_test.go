@@ -0,0 +1,67 @@
+package gotextfsm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnexpectedStatesReturnErrors(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString("Value List beer (\\S+)\n\nStart\n  ^${beer} -> Record\n"); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+
+	// Unknown state
+	out := ParserOutput{cur_state_name: "Nowhere"}
+	err := out.ParseTextString("Hello\n", fsm, true)
+	if err == nil || !strings.Contains(err.Error(), "Unknown State Nowhere") {
+		t.Errorf("Expected error for unknown state. Got '%v'", err)
+	}
+
+	// Value holding data of a type it can never hold
+	out = ParserOutput{}
+	out.Reset(fsm)
+	value := out.values["beer"]
+	value.curval = 10
+	out.values["beer"] = value
+	err = out.ParseTextString("Hello\n", fsm, true)
+	if err == nil || !strings.Contains(err.Error(), "Unknown data type int for beer") {
+		t.Errorf("Expected error for unknown data type. Got '%v'", err)
+	}
+	var internal *InternalError
+	if errors.As(err, &internal) {
+		t.Errorf("Expected a regular error. Got internal error '%s'", err)
+	}
+}
+
+func TestInternalError(t *testing.T) {
+	// A TextFSM that is not built by parsing a template. The rule has no compiled regex.
+	fsm := TextFSM{
+		Values: map[string]TextFSMValue{},
+		States: map[string]TextFSMState{"Start": {name: "Start", rules: []TextFSMRule{{Match: "^Hello"}}}},
+	}
+	out := ParserOutput{}
+	err := out.ParseTextString("Hello\n", fsm, true)
+	var internal *InternalError
+	if !errors.As(err, &internal) {
+		t.Fatalf("Expected internal error. Got '%v'", err)
+	}
+	if internal.Op != "ParseText" || internal.State != "Start" || internal.Line != 1 {
+		t.Errorf("Context of the error does not match. Got (%s, %s, %d)", internal.Op, internal.State, internal.Line)
+	}
+	if len(internal.Stack) == 0 {
+		t.Errorf("Expected stack trace in the error")
+	}
+	if !strings.HasPrefix(err.Error(), "Internal error in ParseText: ") || !strings.HasSuffix(err.Error(), ". State: Start. Line: 1") {
+		t.Errorf("Unexpected error message '%s'", err)
+	}
+	if internal.Unwrap() == nil {
+		t.Errorf("Expected runtime error as the cause")
+	}
+
+	err = WriteJSON(nil, fsm, []map[string]interface{}{{}})
+	if !errors.As(err, &internal) || internal.Op != "WriteJSON" {
+		t.Errorf("Expected internal error from WriteJSON. Got '%v'", err)
+	}
+}
@@ -0,0 +1,163 @@
+package gotextfsm
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// RuleExplanation tells how far the regex of a rule got in matching a line. See ExplainLine.
+type RuleExplanation struct {
+	RuleLine int    // Line number of the rule in the template
+	Match    string // Regex of the rule as written in the template (before substitution of Values)
+	Matched  bool   // True if the rule matches the line
+	// Longest leading part of the regex (after substitution of Values) that matches the line
+	// and the text of the line matched by it.
+	MatchedPattern string
+	MatchedText    string
+	// Offset (in bytes) of the first character of the line that the rest of the regex could not match.
+	// Length of the line if the line ended. End of the match if Matched.
+	Position int
+	// First character of the line that could not be matched (at Position). Empty if the line ended or if Matched.
+	Diverged string
+	// Part of the regex that failed to match at Position. Empty if Matched.
+	FailedPattern string
+	// Name of the Value, the sub pattern of which failed to match. Empty if the failed part is not in a Value.
+	Value string
+}
+
+func (e RuleExplanation) String() string {
+	if e.Matched {
+		return fmt.Sprintf("Line %d: '%s' matched '%s'", e.RuleLine, e.Match, e.MatchedText)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Line %d: '%s' matched '%s'. Failed ", e.RuleLine, e.Match, e.MatchedText))
+	if e.Diverged == "" {
+		sb.WriteString("at end of line")
+	} else {
+		sb.WriteString(fmt.Sprintf("at offset %d ('%s')", e.Position, e.Diverged))
+	}
+	sb.WriteString(fmt.Sprintf(" on '%s'", e.FailedPattern))
+	if e.Value != "" {
+		sb.WriteString(fmt.Sprintf(" of $%s", e.Value))
+	}
+	return sb.String()
+}
+
+// regexPart is one step of the regex of a rule, as seen by ExplainLine.
+type regexPart struct {
+	re    *syntax.Regexp
+	value string // Name of the Value the part belongs to. Empty if none.
+}
+
+// String returns the part as a regex, that can be concatenated with the other parts.
+func (p regexPart) String() string {
+	switch p.re.Op {
+	case syntax.OpBeginText:
+		return "^"
+	case syntax.OpEndText:
+		return "$"
+	case syntax.OpAlternate:
+		return "(?:" + p.re.String() + ")"
+	}
+	return p.re.String()
+}
+
+// ExplainLine passes the line through each rule of the state, like parsing does, and explains how far each rule got.
+// Rules are not stopped at the first match and no Values or records are changed. It is meant for debugging templates.
+//
+// The regex of the rule is split into a sequence of parts: Each character of a literal, and each
+// repetition, character class, alternation etc. Captures (including $Values) are looked into.
+// The longest leading sequence of parts that matches the line tells where matching diverged.
+//     Args:
+//       state: Name of the state
+//       line: Input line
+//     Returns:
+//       Explanation for each rule of the state, in the order of the rules
+//       error if the state does not exist
+func (t *TextFSM) ExplainLine(state string, line string) (explanations []RuleExplanation, err error) {
+	defer t.recoverInternalError("ExplainLine", &err)
+	st, exists := t.States[state]
+	if !exists {
+		return nil, fmt.Errorf("State '%s' not found in template", state)
+	}
+	explanations = make([]RuleExplanation, 0, len(st.rules))
+	for _, rule := range st.rules {
+		explanation, err := rule.explain(line)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", rule.LineNum, err)
+		}
+		explanations = append(explanations, explanation)
+	}
+	return explanations, nil
+}
+
+// explain returns the explanation of how far the regex of the rule got in matching the line.
+func (r *TextFSMRule) explain(line string) (RuleExplanation, error) {
+	e := RuleExplanation{RuleLine: r.LineNum, Match: r.Match}
+	if loc := r.regex.FindStringIndex(line); loc != nil {
+		e.Matched = true
+		e.MatchedPattern = r.Regex
+		e.MatchedText = line[loc[0]:loc[1]]
+		e.Position = loc[1]
+		return e, nil
+	}
+	re, err := syntax.Parse(r.Regex, syntax.Perl)
+	if err != nil {
+		return e, err
+	}
+	parts := make([]regexPart, 0)
+	r.flattenParts(re, "", &parts)
+	// A prefix of a match is a match of the prefix. Hence, the longest matching prefix is found from the longest.
+	for k := len(parts) - 1; k >= 0; k-- {
+		var sb strings.Builder
+		for _, part := range parts[:k] {
+			sb.WriteString(part.String())
+		}
+		prefix, err := regexp.Compile(sb.String())
+		if err != nil {
+			return e, err
+		}
+		// Leftmost longest, to get as far as possible into the line.
+		prefix.Longest()
+		loc := prefix.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		e.MatchedPattern = sb.String()
+		e.MatchedText = line[loc[0]:loc[1]]
+		e.Position = loc[1]
+		e.FailedPattern = parts[k].String()
+		e.Value = parts[k].value
+		if e.Position < len(line) {
+			ch, _ := utf8.DecodeRuneInString(line[e.Position:])
+			e.Diverged = string(ch)
+		}
+		break
+	}
+	return e, nil
+}
+
+// flattenParts appends the parts of re to parts. See ExplainLine.
+// value is the name of the Value, the group of which encloses re. Empty if there is none.
+func (r *TextFSMRule) flattenParts(re *syntax.Regexp, value string, parts *[]regexPart) {
+	switch re.Op {
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			r.flattenParts(sub, value, parts)
+		}
+	case syntax.OpCapture:
+		if _, exists := r.groups[re.Name]; exists && value == "" {
+			value = re.Name
+		}
+		r.flattenParts(re.Sub[0], value, parts)
+	case syntax.OpLiteral:
+		for _, ch := range re.Rune {
+			*parts = append(*parts, regexPart{re: &syntax.Regexp{Op: syntax.OpLiteral, Flags: re.Flags, Rune: []rune{ch}}, value: value})
+		}
+	default:
+		*parts = append(*parts, regexPart{re: re, value: value})
+	}
+}
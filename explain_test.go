@@ -0,0 +1,79 @@
+package gotextfsm
+
+import (
+	"testing"
+)
+
+func TestExplainLine(t *testing.T) {
+	template := `Value Interface (\S+)
+Value Status (up|down)
+Value Name ((?P<first>\w+)\s(?P<last>\w+))
+
+Start
+  ^Interface ${Interface} is ${Status} -> Record
+  ^Interfaces?\s+total
+  ^User ${Name}\.
+  ^\s*$$
+`
+	fsm := TextFSM{}
+	if err := fsm.ParseString(template); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	testcases := []struct {
+		line     string
+		expected []RuleExplanation
+	}{
+		{
+			line: "Interface eth1 is administratively down",
+			expected: []RuleExplanation{
+				{RuleLine: 6, Match: `^Interface ${Interface} is ${Status}`, MatchedPattern: `^Interface [^\t\n\f\r ]+ is `, MatchedText: "Interface eth1 is ", Position: 18, Diverged: "a", FailedPattern: "(?:up|down)", Value: "Status"},
+				{RuleLine: 7, Match: `^Interfaces?\s+total`, MatchedPattern: `^Interfaces?[\t\n\f\r ]+`, MatchedText: "Interface ", Position: 10, Diverged: "e", FailedPattern: "t"},
+				{RuleLine: 8, Match: `^User ${Name}\.`, MatchedPattern: `^`, MatchedText: "", Position: 0, Diverged: "I", FailedPattern: "U"},
+				{RuleLine: 9, Match: `^\s*$$`, MatchedPattern: `^[\t\n\f\r ]*`, MatchedText: "", Position: 0, Diverged: "I", FailedPattern: "$"},
+			},
+		},
+		{
+			line: "User John",
+			expected: []RuleExplanation{
+				{RuleLine: 6, Match: `^Interface ${Interface} is ${Status}`, MatchedPattern: `^`, Position: 0, Diverged: "U", FailedPattern: "I"},
+				{RuleLine: 7, Match: `^Interfaces?\s+total`, MatchedPattern: `^`, Position: 0, Diverged: "U", FailedPattern: "I"},
+				{RuleLine: 8, Match: `^User ${Name}\.`, MatchedPattern: `^User [0-9A-Z_a-z]+`, MatchedText: "User John", Position: 9, FailedPattern: `[\t\n\f\r ]`, Value: "Name"},
+				{RuleLine: 9, Match: `^\s*$$`, MatchedPattern: `^[\t\n\f\r ]*`, Position: 0, Diverged: "U", FailedPattern: "$"},
+			},
+		},
+		{
+			line: "Interface eth0 is up",
+			expected: []RuleExplanation{
+				{RuleLine: 6, Match: `^Interface ${Interface} is ${Status}`, Matched: true, MatchedPattern: `^Interface (?P<Interface>\S+) is (?P<Status>up|down)`, MatchedText: "Interface eth0 is up", Position: 20},
+				{RuleLine: 7, Match: `^Interfaces?\s+total`, MatchedPattern: `^Interfaces?[\t\n\f\r ]+`, MatchedText: "Interface ", Position: 10, Diverged: "e", FailedPattern: "t"},
+				{RuleLine: 8, Match: `^User ${Name}\.`, MatchedPattern: `^`, Position: 0, Diverged: "I", FailedPattern: "U"},
+				{RuleLine: 9, Match: `^\s*$$`, MatchedPattern: `^[\t\n\f\r ]*`, Position: 0, Diverged: "I", FailedPattern: "$"},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		explanations, err := fsm.ExplainLine("Start", tc.line)
+		if err != nil {
+			t.Errorf("'%s' failed. Expected no error. But found error %s", tc.line, err)
+			continue
+		}
+		if len(explanations) != len(tc.expected) {
+			t.Errorf("'%s' failed. Number of explanations dont match (%d, %d)", tc.line, len(tc.expected), len(explanations))
+			continue
+		}
+		for i, e := range explanations {
+			if e != tc.expected[i] {
+				t.Errorf("'%s' failed. Explanations dont match\n%#v\n%#v", tc.line, tc.expected[i], e)
+			}
+		}
+	}
+
+	explanations, _ := fsm.ExplainLine("Start", "Interface eth1 is administratively down")
+	expected := `Line 6: '^Interface ${Interface} is ${Status}' matched 'Interface eth1 is '. Failed at offset 18 ('a') on '(?:up|down)' of $Status`
+	if explanations[0].String() != expected {
+		t.Errorf("Strings dont match\n%s\n%s", expected, explanations[0].String())
+	}
+	if _, err := fsm.ExplainLine("Nowhere", "Hello"); err == nil {
+		t.Errorf("Expected error for unknown state")
+	}
+}
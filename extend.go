@@ -0,0 +1,271 @@
+package gotextfsm
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Label of the rule that follows the comment. ex. '  # @label interface'
+var LABEL_RE = regexp.MustCompile(`^\s*#\s*@label\s+(\w+)\s*$`)
+
+// Block of rules to insert before or after a labelled rule. ex. '@after interface'
+var INSERT_RE = regexp.MustCompile(`^@(before|after)\s+(\w+)$`)
+
+// templateBlock is a state (or a block of rules to insert) of a template, with the comments before it.
+type templateBlock struct {
+	name   string // Name of the state. Label of the rule, for an insert block.
+	insert string // 'before' or 'after' for an insert block. Empty for a state.
+	header templateLine
+	lines  []templateLine // Comments before the header, the header and the rules.
+}
+
+// load reads the template with the includes and macros expanded (See read) and merges it with the template it extends.
+//
+// 'Extends path' as the first line (other than comments) of a template makes it extend the template at path
+// (relative to the directory of the template). The base template is read first. Then, the template can:
+//     * Add Values, or override the Values of the base template by declaring them again. Overridden Values keep their place.
+//     * Add states, or replace the states of the base template (with all their rules) by declaring them again.
+//     * Insert rules before or after a rule of the base template labelled with a comment '# @label NAME' before it.
+//       The rules go in a block that starts with '@before NAME' or '@after NAME' (in place of the name of a state).
+// Macros of the base template can be used in the template. A template that extends itself (directly or not) is an error.
+func (r *templateReader) load(name string) ([]templateLine, error) {
+	if FindIndex(r.extending, name) >= 0 {
+		return nil, fmt.Errorf("Extends loop: %s", strings.Join(append(append([]string{}, r.extending...), name), " -> "))
+	}
+	r.extending = append(r.extending, name)
+	defer func() { r.extending = r.extending[:len(r.extending)-1] }()
+	data, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	// The base template is read first. So that its macros can be used in the lines of the template.
+	var base_lines []templateLine
+	for i, text := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(text), "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "Extends ") {
+			base := path.Join(path.Dir(name), strings.TrimSpace(strings.TrimPrefix(text, "Extends ")))
+			if base_lines, err = r.load(base); err != nil {
+				if _, ok := err.(*TemplateError); ok {
+					return nil, err
+				}
+				return nil, &TemplateError{File: name, Line: i + 1, Err: err}
+			}
+		}
+		break
+	}
+	saved := r.lines
+	r.lines = nil
+	err = r.read(name)
+	lines := r.lines
+	r.lines = saved
+	if err != nil {
+		return nil, err
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line.text, "Extends ") {
+			continue
+		}
+		if base_lines == nil || line.file != name || i != countComments(lines) {
+			return nil, &TemplateError{File: line.file, Line: line.line, Err: fmt.Errorf("Extends must be the first line of the template")}
+		}
+	}
+	if base_lines == nil {
+		_, blocks := splitTemplate(lines)
+		for _, block := range blocks {
+			if block.insert != "" {
+				return nil, &TemplateError{File: block.header.file, Line: block.header.line, Err: fmt.Errorf("'@%s' can be used only in a template that extends another", block.insert)}
+			}
+		}
+		return lines, nil
+	}
+	idx := countComments(lines)
+	return mergeTemplates(base_lines, append(append([]templateLine{}, lines[:idx]...), lines[idx+1:]...))
+}
+
+// countComments returns the number of comment lines at the start of the lines.
+func countComments(lines []templateLine) int {
+	count := 0
+	for count < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[count].text), "#") {
+		count++
+	}
+	return count
+}
+
+// splitTemplate splits the lines of a template into the Values and the blocks of the states.
+func splitTemplate(lines []templateLine) ([]templateLine, []templateBlock) {
+	values := make([]templateLine, 0)
+	idx := 0
+	for ; idx < len(lines) && strings.TrimSpace(lines[idx].text) != ""; idx++ {
+		values = append(values, lines[idx])
+	}
+	blocks := make([]templateBlock, 0)
+	var block *templateBlock
+	for ; idx < len(lines); idx++ {
+		line := lines[idx]
+		if strings.TrimSpace(line.text) == "" {
+			if block != nil && block.header.text != "" {
+				blocks = append(blocks, *block)
+				block = nil
+			}
+			continue
+		}
+		if block == nil {
+			block = &templateBlock{}
+		}
+		block.lines = append(block.lines, line)
+		if block.header.text != "" || strings.HasPrefix(strings.TrimSpace(line.text), "#") {
+			continue
+		}
+		block.header = line
+		block.name = line.text
+		if m := INSERT_RE.FindStringSubmatch(line.text); m != nil {
+			block.insert = m[1]
+			block.name = m[2]
+		}
+	}
+	if block != nil && block.header.text != "" {
+		blocks = append(blocks, *block)
+	}
+	return values, blocks
+}
+
+// valueName returns the name of the Value declared by the line. ex. 'Value Required,Filldown Name (\S+)' is 'Name'
+func valueName(text string) string {
+	tokens := strings.Fields(text)
+	if len(tokens) < 3 {
+		return ""
+	}
+	if strings.HasPrefix(tokens[2], "(") {
+		return tokens[1]
+	}
+	return tokens[2]
+}
+
+// mergeTemplates merges the lines of the template (without the 'Extends' line) into the lines of the base template.
+// See load.
+func mergeTemplates(base []templateLine, lines []templateLine) ([]templateLine, error) {
+	base_values, base_blocks := splitTemplate(base)
+	values, blocks := splitTemplate(lines)
+	for _, line := range values {
+		idx := -1
+		if strings.HasPrefix(line.text, "Value ") {
+			for i, base_line := range base_values {
+				if strings.HasPrefix(base_line.text, "Value ") && valueName(base_line.text) == valueName(line.text) {
+					idx = i
+				}
+			}
+		}
+		if idx >= 0 {
+			base_values[idx] = line
+		} else {
+			base_values = append(base_values, line)
+		}
+	}
+	inserts := make([]templateBlock, 0)
+	for _, block := range blocks {
+		if block.insert != "" {
+			inserts = append(inserts, block)
+			continue
+		}
+		replaced := false
+		for i := range base_blocks {
+			if base_blocks[i].insert == "" && base_blocks[i].name == block.name {
+				base_blocks[i] = block
+				replaced = true
+			}
+		}
+		if !replaced {
+			base_blocks = append(base_blocks, block)
+		}
+	}
+	for _, insert := range inserts {
+		if err := insertRules(base_blocks, insert); err != nil {
+			return nil, err
+		}
+	}
+	merged := append([]templateLine{}, base_values...)
+	for _, block := range base_blocks {
+		merged = append(merged, templateLine{})
+		merged = append(merged, block.lines...)
+	}
+	return merged, nil
+}
+
+// insertRules inserts the rules of the insert block before or after the rule with the label of the block.
+// The label must be found exactly once in the states.
+func insertRules(blocks []templateBlock, insert templateBlock) error {
+	rules := make([]templateLine, 0)
+	for _, line := range insert.lines {
+		if line != insert.header && !strings.HasPrefix(strings.TrimSpace(line.text), "#") {
+			rules = append(rules, line)
+		}
+	}
+	// Block and line of the label.
+	found_block, found_line := -1, -1
+	for b := range blocks {
+		if blocks[b].insert != "" {
+			continue
+		}
+		for i, line := range blocks[b].lines {
+			m := LABEL_RE.FindStringSubmatch(line.text)
+			if m == nil || m[1] != insert.name {
+				continue
+			}
+			if found_block >= 0 {
+				return &TemplateError{File: line.file, Line: line.line, Err: fmt.Errorf("Duplicate label '%s'", insert.name)}
+			}
+			found_block, found_line = b, i
+		}
+	}
+	if found_block < 0 {
+		return &TemplateError{File: insert.header.file, Line: insert.header.line, Err: fmt.Errorf("Label '%s' not found", insert.name)}
+	}
+	lines := blocks[found_block].lines
+	label := lines[found_line]
+	// The rule labelled is the next line that is not a comment.
+	rule := found_line + 1
+	for rule < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[rule].text), "#") {
+		rule++
+	}
+	if rule >= len(lines) {
+		return &TemplateError{File: label.file, Line: label.line, Err: fmt.Errorf("Label '%s' is not followed by a rule", insert.name)}
+	}
+	at := found_line
+	if insert.insert == "after" {
+		at = rule + 1
+	}
+	updated := append([]templateLine{}, lines[:at]...)
+	updated = append(updated, rules...)
+	blocks[found_block].lines = append(updated, lines[at:]...)
+	return nil
+}
+
+// Dump returns the template as it was parsed, after the includes, macros and 'Extends' are resolved.
+// Each Value and rule is preceded by a comment with the file and the line it was read from (See Origin), if known.
+// The dump can be parsed again as a template.
+func (t *TextFSM) Dump() string {
+	var sb strings.Builder
+	for _, name := range t.value_names {
+		value := t.Values[name]
+		if value.origin != "" {
+			sb.WriteString("# " + value.origin + "\n")
+		}
+		sb.WriteString(value.String() + "\n")
+	}
+	for _, name := range t.state_names {
+		state := t.States[name]
+		sb.WriteString("\n" + name + "\n")
+		for _, rule := range state.rules {
+			if rule.origin != "" {
+				sb.WriteString("  # " + rule.origin + "\n")
+			}
+			sb.WriteString(" " + rule.String() + "\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,163 @@
+package gotextfsm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var extendFS = fstest.MapFS{
+	"base/interfaces.textfsm": &fstest.MapFile{Data: []byte(`# Interfaces of any vendor
+Macro IFNAME \S+
+Value Required Interface (${IFNAME})
+Value Status (up|down)
+
+Start
+  ^interface -> Continue.Record
+  # @label interface
+  ^interface ${Interface} is ${Status}
+`)},
+	"vendor/interfaces.textfsm": &fstest.MapFile{Data: []byte(`# Interfaces of a vendor
+Extends ../base/interfaces.textfsm
+Value Status (up|down|admin-down)
+Value Mtu (\d+)
+
+@after interface
+  ^\s+MTU ${Mtu}
+
+@before interface
+  ^\s*$$ -> Next
+`)},
+	"vendor/replace.textfsm": &fstest.MapFile{Data: []byte(`Extends ../base/interfaces.textfsm
+
+Start
+  ^port ${Interface} -> Record
+`)},
+	"loop/a.textfsm":         &fstest.MapFile{Data: []byte("Extends b.textfsm\n")},
+	"loop/b.textfsm":         &fstest.MapFile{Data: []byte("Extends a.textfsm\n")},
+	"bad/late.textfsm":       &fstest.MapFile{Data: []byte("Value Foo (\\S+)\nExtends ../base/interfaces.textfsm\n")},
+	"base/duplicate.textfsm": &fstest.MapFile{Data: []byte("Value Foo (\\S+)\n\nStart\n  # @label foo\n  ^a${Foo}\n  # @label foo\n  ^b${Foo}\n")},
+	"bad/duplicate.textfsm":  &fstest.MapFile{Data: []byte("Extends ../base/duplicate.textfsm\n\n@after foo\n  ^c\n")},
+	"bad/label.textfsm":      &fstest.MapFile{Data: []byte("Extends ../base/interfaces.textfsm\n\n@after nothing\n  ^foo\n")},
+	"bad/no_extends.textfsm": &fstest.MapFile{Data: []byte("Value Foo (\\S+)\n\nStart\n  ^${Foo}\n\n@after interface\n  ^foo\n")},
+}
+
+func TestExtends(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseFS(extendFS, "vendor/interfaces.textfsm"); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	if !reflect.DeepEqual(fsm.ValueNames(), []string{"Interface", "Status", "Mtu"}) {
+		t.Errorf("Values dont match (%v)", fsm.ValueNames())
+	}
+	origins := map[string]string{
+		"Interface": "base/interfaces.textfsm:3",
+		"Status":    "vendor/interfaces.textfsm:3",
+		"Mtu":       "vendor/interfaces.textfsm:4",
+	}
+	for name, origin := range origins {
+		if value := fsm.Values[name]; value.Origin() != origin {
+			t.Errorf("Origin of Value %s dont match ('%s', '%s')", name, origin, value.Origin())
+		}
+	}
+	rules := fsm.States["Start"].rules
+	expected := []string{
+		"base/interfaces.textfsm:7",
+		"vendor/interfaces.textfsm:10",
+		"base/interfaces.textfsm:9",
+		"vendor/interfaces.textfsm:7",
+	}
+	if len(rules) != len(expected) {
+		t.Fatalf("Rules dont match (%d, %d)", len(expected), len(rules))
+	}
+	for i, origin := range expected {
+		if rules[i].Origin() != origin {
+			t.Errorf("Origin of rule %d dont match ('%s', '%s')", i, origin, rules[i].Origin())
+		}
+	}
+	out := ParserOutput{}
+	data := "interface Gi0/1 is admin-down\n  MTU 1500\n\ninterface Gi0/2 is up\n"
+	if err := out.ParseTextString(data, fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	records := []map[string]interface{}{
+		{"Interface": "Gi0/1", "Status": "admin-down", "Mtu": "1500"},
+		{"Interface": "Gi0/2", "Status": "up", "Mtu": ""},
+	}
+	if !reflect.DeepEqual(out.Dict, records) {
+		t.Errorf("Records dont match (%v, %v)", records, out.Dict)
+	}
+
+	// The dump parses to the same template.
+	dumped := TextFSM{}
+	if err := dumped.ParseString(fsm.Dump()); err != nil {
+		t.Fatalf("Parsing the dump failed with error '%s'\n%s", err, fsm.Dump())
+	}
+	if dumped.Dump() != stripOrigins(fsm.Dump()) {
+		t.Errorf("Dumps dont match\n%s\n%s", stripOrigins(fsm.Dump()), dumped.Dump())
+	}
+}
+
+// stripOrigins removes the origin comments of a dump.
+func stripOrigins(dump string) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(dump, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestExtendsReplaceState(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseFS(extendFS, "vendor/replace.textfsm"); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	rules := fsm.States["Start"].rules
+	if len(rules) != 1 || rules[0].Origin() != "vendor/replace.textfsm:4" {
+		t.Errorf("Rules of the replaced state dont match (%v)", rules)
+	}
+}
+
+func TestExtendsErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		template string
+		err      string
+	}{
+		{
+			name:     "Extends loop",
+			template: "loop/a.textfsm",
+			err:      "loop/b.textfsm:1: Extends loop: loop/a.textfsm -> loop/b.textfsm -> loop/a.textfsm",
+		},
+		{
+			name:     "Extends not on the first line",
+			template: "bad/late.textfsm",
+			err:      "bad/late.textfsm:2: Extends must be the first line of the template",
+		},
+		{
+			name:     "Unknown label",
+			template: "bad/label.textfsm",
+			err:      "bad/label.textfsm:3: Label 'nothing' not found",
+		},
+		{
+			name:     "Duplicate label in a state",
+			template: "bad/duplicate.textfsm",
+			err:      "base/duplicate.textfsm:6: Duplicate label 'foo'",
+		},
+		{
+			name:     "Insert without Extends",
+			template: "bad/no_extends.textfsm",
+			err:      "bad/no_extends.textfsm:6: '@after' can be used only in a template that extends another",
+		},
+	}
+	for _, tc := range cases {
+		fsm := TextFSM{}
+		err := fsm.ParseFS(extendFS, tc.template)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("'%s' failed. Errors dont match ('%s', '%v')", tc.name, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package gotextfsm
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Fuzz targets for the inputs that come from users: templates, text to parse and rules.
+// Run with ex. 'go test -fuzz FuzzParseText'. Without -fuzz, only the seeds are run as regular tests.
+
+func FuzzTemplateParse(f *testing.F) {
+	for _, tc := range fsmtestcases {
+		f.Add(tc.input)
+	}
+	for _, tc := range parseTestCases {
+		f.Add(tc.template)
+	}
+	f.Fuzz(func(t *testing.T, template string) {
+		fsm := TextFSM{}
+		if err := fsm.ParseString(template); err != nil {
+			return
+		}
+		// A valid template must have a valid 'Start' state.
+		if _, exists := fsm.GetState("Start"); !exists {
+			t.Errorf("Template is valid but 'Start' state not found")
+		}
+		// Names must list each Value and state once, in the order of declaration.
+		if !sameNames(fsm.ValueNames(), len(fsm.Values), func(name string) bool { _, exists := fsm.Values[name]; return exists }) {
+			t.Errorf("Value names dont match the Values (%v)", fsm.ValueNames())
+		}
+		if !sameNames(fsm.StateNames(), len(fsm.States), func(name string) bool { _, exists := fsm.States[name]; return exists }) {
+			t.Errorf("State names dont match the states (%v)", fsm.StateNames())
+		}
+	})
+}
+
+func FuzzParseText(f *testing.F) {
+	for _, tc := range parseTestCases {
+		f.Add(tc.template, tc.data)
+	}
+	f.Fuzz(func(t *testing.T, template string, data string) {
+		fsm := TextFSM{}
+		if err := fsm.ParseString(template); err != nil {
+			return
+		}
+		out := ParserOutput{}
+		if err := out.ParseTextString(data, fsm, true); err != nil {
+			return
+		}
+		// Parsing the same data in chunks must give the same records.
+		chunked := ParserOutput{}
+		for i := 0; i < len(data); i += 3 {
+			end := i + 3
+			if end > len(data) {
+				end = len(data)
+			}
+			if err := chunked.ParseChunk(data[i:end], fsm); err != nil {
+				t.Fatalf("Chunked parsing failed with error '%s'", err)
+			}
+		}
+		if err := chunked.Close(fsm); err != nil {
+			t.Fatalf("Chunked parsing failed with error '%s'", err)
+		}
+		if !reflect.DeepEqual(out.Dict, chunked.Dict) {
+			t.Errorf("Records dont match (%v, %v)", out.Dict, chunked.Dict)
+		}
+	})
+}
+
+// sameNames returns true if the names are unique, count in number and each of them exists.
+func sameNames(names []string, count int, exists func(string) bool) bool {
+	if len(names) != count {
+		return false
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] || !exists(name) {
+			return false
+		}
+		seen[name] = true
+	}
+	return true
+}
+
+func FuzzRuleParse(f *testing.F) {
+	for _, tc := range ruleTestCases {
+		f.Add(tc.input)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		rule := TextFSMRule{}
+		rule.Parse(line, 1, map[string]interface{}{"beer": "(?P<beer>.*)"})
+	})
+}
+
+func FuzzPythonTemplate(f *testing.F) {
+	for _, tc := range pyTemplateTestcases {
+		f.Add(tc.input)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		ExecutePythonTemplate(input, map[string]interface{}{"world": "Siri", "world123": "Bigger", "temp": "$$ {{ }}"})
+	})
+}
+
+func FuzzParseSyntax(f *testing.F) {
+	for _, tc := range fsmtestcases {
+		f.Add(tc.input)
+	}
+	f.Fuzz(func(t *testing.T, template string) {
+		// The syntax tree must be lossless for any input.
+		if tree := ParseSyntax(template); tree.String() != template {
+			t.Errorf("Source dont match ('%q', '%q')", template, tree.String())
+		}
+	})
+}
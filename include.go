@@ -0,0 +1,254 @@
+package gotextfsm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// TemplateError is an error in a template read by ParseFS, with the file and the line of the file it was found in.
+// Line is 0 if the error is not on a particular line. (ex. Missing 'Start' state)
+type TemplateError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *TemplateError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err.Error())
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Err.Error())
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// Macro definition. ex. 'Macro IPV4 (?:\d{1,3}\.){3}\d{1,3}'
+var MACRO_RE = regexp.MustCompile(`^Macro\s+(\w+)\s+(.*)$`)
+
+// templateLine is a line of a template, after the includes and macros are expanded.
+type templateLine struct {
+	file string
+	line int
+	text string
+}
+
+// templateReader reads a template and the templates it includes from a file system. See ParseFS.
+type templateReader struct {
+	fsys      fs.FS
+	macros    map[string]string
+	defined   map[string]templateLine // File and line each macro is defined in.
+	stack     []string                // Files being read. The last one includes none.
+	extending []string                // Templates being loaded. The last one extends none. See load.
+	lines     []templateLine
+}
+
+// ParseFS reads the template 'name' from fsys and parses it, like ParseString.
+// In addition, the template can have the following directives, each on a line of its own:
+//     Include path: The lines of the file (relative to the directory of the template) are read in place of the directive.
+//                   Blank lines at the end of the file are ignored. So that a file of Values can be included in the Values.
+//     Macro NAME regex: ${NAME} in the Values and rules that follow is replaced by regex.
+//                       Macros can be used in other macros too.
+// ex. A library of shared Values and macros:
+//     Macro IPV4 (?:\d{1,3}\.){3}\d{1,3}
+//     Value Required Interface (\S+)
+// and a template that uses it:
+//     Include lib/common.textfsm
+//     Value Address (${IPV4})
+//
+// A template can also extend another template. See 'Extends' in load.
+//
+// Errors are returned as *TemplateError, with the file and the line in which they were found.
+// Includes that include themselves (directly or not) are errors.
+// Each Value and rule remembers the file and the line it was read from. See Origin and Dump.
+func (t *TextFSM) ParseFS(fsys fs.FS, name string) (err error) {
+	defer t.recoverInternalError("ParseTemplate", &err)
+	reader := templateReader{fsys: fsys, macros: make(map[string]string), defined: make(map[string]templateLine)}
+	lines, err := reader.load(name)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(line.text + "\n")
+	}
+	if err := t.ParseString(sb.String()); err != nil {
+		if t.line_num >= 1 && t.line_num <= len(lines) && lines[t.line_num-1].file != "" {
+			line := lines[t.line_num-1]
+			return &TemplateError{File: line.file, Line: line.line, Err: stripLineNum(err, t.line_num)}
+		}
+		return &TemplateError{File: name, Err: err}
+	}
+	for macro := range reader.macros {
+		if _, exists := t.Values[macro]; exists {
+			return &TemplateError{File: name, Err: fmt.Errorf("Macro '%s' has the same name as a Value", macro)}
+		}
+	}
+	t.setOrigins(lines)
+	return nil
+}
+
+// stripLineNum removes the line number of the template that was parsed from the message of the error.
+// As it is not the line of the file the error is in. ex. '3 Line: Invalid state name' is 'Invalid state name'
+func stripLineNum(err error, line_num int) error {
+	msg := err.Error()
+	for _, pattern := range []string{`^%d Line: `, `^Line %d: `} {
+		msg = regexp.MustCompile(fmt.Sprintf(pattern, line_num)).ReplaceAllString(msg, "")
+	}
+	msg = regexp.MustCompile(fmt.Sprintf(`\. Line: %d\.?$`, line_num)).ReplaceAllString(msg, ".")
+	if msg == err.Error() {
+		return err
+	}
+	return errors.New(msg)
+}
+
+// String returns the file and the line of the template line. ex. 'base.textfsm:12'
+func (l templateLine) String() string {
+	if l.file == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", l.file, l.line)
+}
+
+// setOrigins sets the file and the line each Value and rule was read from.
+// lines are the lines of the template that was parsed.
+func (t *TextFSM) setOrigins(lines []templateLine) {
+	for _, line := range lines {
+		if !strings.HasPrefix(line.text, "Value ") {
+			continue
+		}
+		// The last definition of a Value is the one that is kept.
+		name := valueName(line.text)
+		if value, exists := t.Values[name]; exists {
+			value.origin = line.String()
+			t.Values[name] = value
+		}
+	}
+	for _, state := range t.States {
+		for i := range state.rules {
+			if idx := state.rules[i].LineNum - 1; idx >= 0 && idx < len(lines) {
+				state.rules[i].origin = lines[idx].String()
+			}
+		}
+	}
+}
+
+// read reads the lines of the file, with the includes and macros expanded.
+func (r *templateReader) read(name string) error {
+	data, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return err
+	}
+	r.stack = append(r.stack, name)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+	for i, text := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		text = strings.TrimSuffix(text, "\r")
+		line_num := i + 1
+		if strings.HasPrefix(text, "Include ") {
+			included := path.Join(path.Dir(name), strings.TrimSpace(strings.TrimPrefix(text, "Include ")))
+			if FindIndex(r.stack, included) >= 0 {
+				loop := strings.Join(append(append([]string{}, r.stack...), included), " -> ")
+				return &TemplateError{File: name, Line: line_num, Err: fmt.Errorf("Include loop: %s", loop)}
+			}
+			if err := r.read(included); err != nil {
+				if _, ok := err.(*TemplateError); ok {
+					return err
+				}
+				return &TemplateError{File: name, Line: line_num, Err: err}
+			}
+			// Blank lines at the end of the included file would end the Values.
+			for len(r.lines) > 0 && r.lines[len(r.lines)-1].file == included && strings.TrimSpace(r.lines[len(r.lines)-1].text) == "" {
+				r.lines = r.lines[:len(r.lines)-1]
+			}
+			continue
+		}
+		if strings.HasPrefix(text, "Macro ") {
+			if err := r.defineMacro(name, line_num, text); err != nil {
+				return &TemplateError{File: name, Line: line_num, Err: err}
+			}
+			continue
+		}
+		expanded, err := r.expand(text, !strings.HasPrefix(text, "Value "))
+		if err != nil {
+			return &TemplateError{File: name, Line: line_num, Err: err}
+		}
+		r.lines = append(r.lines, templateLine{file: name, line: line_num, text: expanded})
+	}
+	return nil
+}
+
+// defineMacro adds the macro defined by the line (line_num of the file). Macros used in the regex are expanded.
+// A file included more than once (ex. a library included by two included files) defines its macros again.
+// That is not a duplicate, as it is the same definition.
+func (r *templateReader) defineMacro(name string, line_num int, text string) error {
+	m := MACRO_RE.FindStringSubmatch(TrimRightSpace(text))
+	if m == nil {
+		return fmt.Errorf("Invalid macro definition '%s'", text)
+	}
+	if defined, exists := r.defined[m[1]]; exists {
+		if defined.file == name && defined.line == line_num {
+			return nil
+		}
+		return fmt.Errorf("Duplicate macro '%s'", m[1])
+	}
+	regex, err := r.expand(m[2], false)
+	if err != nil {
+		return err
+	}
+	if _, err := regexp.Compile(regex); err != nil {
+		return fmt.Errorf("Invalid regular expression '%s' in macro '%s'. Error: '%s'", regex, m[1], err.Error())
+	}
+	r.macros[m[1]] = regex
+	r.defined[m[1]] = templateLine{file: name, line: line_num, text: text}
+	return nil
+}
+
+// expand replaces ${NAME} of the macros in the line. '$$' is an escape and is left as is.
+// Other ${NAME}s are left as is in rules, as they are Values. But they are errors in Values and macros.
+// In rules, '$' of the regex of the macro is escaped as '$$'. As rules are Python templates.
+func (r *templateReader) expand(text string, rule bool) (string, error) {
+	if !strings.Contains(text, "${") {
+		return text, nil
+	}
+	var sb strings.Builder
+	i := 0
+	for {
+		idx := strings.IndexByte(text[i:], '$')
+		if idx < 0 {
+			sb.WriteString(text[i:])
+			break
+		}
+		sb.WriteString(text[i : i+idx])
+		i += idx
+		rest := text[i+1:]
+		if strings.HasPrefix(rest, "$") {
+			sb.WriteString("$$")
+			i += 2
+			continue
+		}
+		if strings.HasPrefix(rest, "{") {
+			name := pyIdentifier(rest[1:])
+			if name != "" && strings.HasPrefix(rest[1+len(name):], "}") {
+				if regex, exists := r.macros[name]; exists {
+					if rule {
+						regex = strings.ReplaceAll(regex, "$", "$$")
+					}
+					sb.WriteString(regex)
+					i += len(name) + 3
+					continue
+				}
+				if !rule {
+					return "", fmt.Errorf("Unknown macro '%s'", name)
+				}
+			}
+		}
+		sb.WriteString("$")
+		i++
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,162 @@
+package gotextfsm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+var includeFS = fstest.MapFS{
+	"lib/common.textfsm": &fstest.MapFile{Data: []byte(`# Shared macros and Values
+Macro OCTET \d{1,3}
+Macro IPV4 (?:${OCTET}\.){3}${OCTET}
+Macro EOL \s*$
+Value Required Interface (\S+)
+
+`)},
+	"templates/show_ip.textfsm": &fstest.MapFile{Data: []byte(`Include ../lib/common.textfsm
+Value Address (${IPV4})
+Value Mask (\d+)
+
+Start
+  ^interface ${Interface}
+  ^ ip address ${Address}/${Mask}${EOL} -> Record
+`)},
+	"templates/loop_a.textfsm":    &fstest.MapFile{Data: []byte("Include loop_b.textfsm\nValue Foo (\\S+)\n\nStart\n  ^${Foo}\n")},
+	"templates/loop_b.textfsm":    &fstest.MapFile{Data: []byte("Value Bar (\\S+)\nInclude loop_a.textfsm\n")},
+	"templates/bad_value.textfsm": &fstest.MapFile{Data: []byte("Include ../lib/common.textfsm\nValue Mask (\\d+\n\nStart\n  ^${Mask}\n")},
+	"templates/bad_lib.textfsm":   &fstest.MapFile{Data: []byte("Include ../lib/bad.textfsm\n\nStart\n  ^${Foo}\n")},
+	"lib/bad.textfsm":             &fstest.MapFile{Data: []byte("Value Foo (\\S+)\nValue Foo Bar\n")},
+	"templates/missing.textfsm":   &fstest.MapFile{Data: []byte("Value Foo (\\S+)\nInclude nothing.textfsm\n\nStart\n  ^${Foo}\n")},
+	"templates/unknown.textfsm":   &fstest.MapFile{Data: []byte("Value Foo (${IPV6})\n\nStart\n  ^${Foo}\n")},
+	"templates/no_start.textfsm":  &fstest.MapFile{Data: []byte("Value Foo (\\S+)\n\nOther\n  ^${Foo}\n")},
+	"templates/clash.textfsm":     &fstest.MapFile{Data: []byte("Macro Foo \\d+\nValue Foo (\\S+)\n\nStart\n  ^${Foo}\n")},
+	"diamond/a.textfsm":           &fstest.MapFile{Data: []byte("Include b.textfsm\nInclude c.textfsm\n\nStart\n  ^${B}\\s+${C}\\s+${L} -> Record\n")},
+	"diamond/b.textfsm":           &fstest.MapFile{Data: []byte("Include lib.textfsm\nValue B (${W})\n")},
+	"diamond/c.textfsm":           &fstest.MapFile{Data: []byte("Include lib.textfsm\nValue C (${W})\n")},
+	"diamond/lib.textfsm":         &fstest.MapFile{Data: []byte("Macro W \\w+\nValue L (${W})\n")},
+	"diamond/redefine.textfsm":    &fstest.MapFile{Data: []byte("Include lib.textfsm\nMacro W \\d+\nValue D (${W})\n\nStart\n  ^${D}\n")},
+	"templates/bad_state.textfsm": &fstest.MapFile{Data: []byte("Value Foo (\\S+)\n\nStart\n  ^${Foo} -> Other\n\nInclude ../lib/states.textfsm\n")},
+	"lib/states.textfsm":          &fstest.MapFile{Data: []byte("Other\n  ^x -> Start\n  ^y -> Missing\n")},
+	"templates/bad_call.textfsm":  &fstest.MapFile{Data: []byte("Value Foo (\\S+)\n\nStart\n  ^${Foo} -> Call Loop\n\nLoop\n  ^x -> Start\n")},
+	"templates/bad_rule.textfsm":  &fstest.MapFile{Data: []byte("Include ../lib/common.textfsm\n\nStart\n  ^${Interface} -> Continue Other\n")},
+}
+
+func TestParseFS(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseFS(includeFS, "templates/show_ip.textfsm"); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	if !reflect.DeepEqual(fsm.ValueNames(), []string{"Interface", "Address", "Mask"}) {
+		t.Errorf("Values dont match (%v)", fsm.ValueNames())
+	}
+	if regex := fsm.Values["Address"].Regex; regex != `((?:\d{1,3}\.){3}\d{1,3})` {
+		t.Errorf("Regex of Address dont match ('%s')", regex)
+	}
+	out := ParserOutput{}
+	data := "interface Gi0/1\n ip address 10.0.0.1/24  \n ip address 10.0.0.300/24\n"
+	if err := out.ParseTextString(data, fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	expected := []map[string]interface{}{
+		{"Interface": "Gi0/1", "Address": "10.0.0.1", "Mask": "24"},
+	}
+	if !reflect.DeepEqual(out.Dict, expected) {
+		t.Errorf("Records dont match (%v, %v)", expected, out.Dict)
+	}
+}
+
+func TestParseFSDiamondInclude(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseFS(includeFS, "diamond/a.textfsm"); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	if !reflect.DeepEqual(fsm.ValueNames(), []string{"L", "B", "C"}) {
+		t.Errorf("Values dont match (%v)", fsm.ValueNames())
+	}
+	out := ParserOutput{}
+	if err := out.ParseTextString("foo bar baz\n", fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	expected := []map[string]interface{}{{"L": "baz", "B": "foo", "C": "bar"}}
+	if !reflect.DeepEqual(out.Dict, expected) {
+		t.Errorf("Records dont match (%v, %v)", expected, out.Dict)
+	}
+}
+
+func TestParseFSErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		template string
+		err      string
+	}{
+		{
+			name:     "Include loop",
+			template: "templates/loop_a.textfsm",
+			err:      "templates/loop_b.textfsm:2: Include loop: templates/loop_a.textfsm -> templates/loop_b.textfsm -> templates/loop_a.textfsm",
+		},
+		{
+			name:     "Error in the template",
+			template: "templates/bad_value.textfsm",
+			err:      "templates/bad_value.textfsm:2: Value '(\\d+' must be contained within a '()' pair.",
+		},
+		{
+			name:     "Error in the included file",
+			template: "templates/bad_lib.textfsm",
+			err:      "lib/bad.textfsm:2: Invalid option Foo",
+		},
+		{
+			name:     "Missing include",
+			template: "templates/missing.textfsm",
+			err:      "templates/missing.textfsm:2: open templates/nothing.textfsm: file does not exist",
+		},
+		{
+			name:     "Unknown macro",
+			template: "templates/unknown.textfsm",
+			err:      "templates/unknown.textfsm:1: Unknown macro 'IPV6'",
+		},
+		{
+			name:     "Error without line",
+			template: "templates/no_start.textfsm",
+			err:      "templates/no_start.textfsm: Missing state 'Start'.",
+		},
+		{
+			name:     "Unknown state in the included file",
+			template: "templates/bad_state.textfsm",
+			err:      "lib/states.textfsm:3: State 'Missing' not found, referenced in state 'Other'",
+		},
+		{
+			name:     "Recursive Call",
+			template: "templates/bad_call.textfsm",
+			err:      "templates/bad_call.textfsm:4: Recursive Call of state 'Loop' from state 'Start'",
+		},
+		{
+			name:     "Line number at the end of the error",
+			template: "templates/bad_rule.textfsm",
+			err:      "templates/bad_rule.textfsm:4: Action 'Continue' with new state Other specified.",
+		},
+		{
+			name:     "Macro defined again in another file",
+			template: "diamond/redefine.textfsm",
+			err:      "diamond/redefine.textfsm:2: Duplicate macro 'W'",
+		},
+		{
+			name:     "Macro with the name of a Value",
+			template: "templates/clash.textfsm",
+			err:      "templates/clash.textfsm: Macro 'Foo' has the same name as a Value",
+		},
+	}
+	for _, tc := range cases {
+		fsm := TextFSM{}
+		err := fsm.ParseFS(includeFS, tc.template)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("'%s' failed. Errors dont match ('%s', '%v')", tc.name, tc.err, err)
+			continue
+		}
+		var template_err *TemplateError
+		if !errors.As(err, &template_err) {
+			t.Errorf("'%s' failed. Expected TemplateError. Got %T", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package gotextfsm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseChunk passes a chunk of CLI output through FSM. It is meant for output that arrives in pieces
+// (ex. read from a live SSH session) where a chunk may end in the middle of a line.
+//
+// Complete lines of the chunk are parsed immediately. The incomplete line at the end of the chunk (if any)
+// is kept and completed by the following chunks. Line numbers continue across the chunks.
+// Lines can be terminated by "\n" or "\r\n".
+//
+// The incomplete line is only searched for its end in the following chunks. So a long line written in
+// small pieces (ex. through Writer) is not copied again on every piece.
+//
+// Once all the chunks are passed, Close must be called to parse the last incomplete line and trigger EOF.
+// If a line fails to parse, the lines after it in the chunk are not parsed. ParserOutput must be Reset
+// before it is used again.
+//     Args:
+//       chunk: (string), Part of the text to parse.
+//       fsm: (TextFSM), TextFSM object as a result of parsing the text fsm template
+//     Returns:
+//       error if there is any error in parsing or if ParserOutput is already closed.
+func (t *ParserOutput) ParseChunk(chunk string, fsm TextFSM) (err error) {
+	defer t.recoverInternalError("ParseChunk", &err)
+	if t.closed {
+		return fmt.Errorf("ParserOutput is closed. Call Reset to parse again.")
+	}
+	t.start(fsm)
+	idx := strings.IndexByte(chunk, '\n')
+	if idx < 0 {
+		t.partial = append(t.partial, chunk...)
+		return nil
+	}
+	// The first line of the chunk completes the incomplete line.
+	line := string(append(t.partial, chunk[:idx]...))
+	t.partial = t.partial[:0]
+	if err := t.parseLine(strings.TrimSuffix(line, "\r"), fsm); err != nil {
+		return err
+	}
+	data := chunk[idx+1:]
+	for {
+		idx := strings.IndexByte(data, '\n')
+		if idx < 0 {
+			break
+		}
+		if err := t.parseLine(strings.TrimSuffix(data[:idx], "\r"), fsm); err != nil {
+			return err
+		}
+		data = data[idx+1:]
+	}
+	t.partial = append(t.partial, data...)
+	return nil
+}
+
+// Close ends the input passed by ParseChunk. The incomplete line (if any) is parsed and EOF is triggered.
+// ParserOutput can not be used with ParseChunk after it is closed, until it is Reset.
+//     Args:
+//       fsm: (TextFSM), TextFSM object as a result of parsing the text fsm template
+//     Returns:
+//       error if there is any error in parsing or if ParserOutput is already closed.
+func (t *ParserOutput) Close(fsm TextFSM) (err error) {
+	defer t.recoverInternalError("Close", &err)
+	if t.closed {
+		return fmt.Errorf("ParserOutput is already closed.")
+	}
+	t.start(fsm)
+	t.closed = true
+	if len(t.partial) > 0 {
+		line := strings.TrimSuffix(string(t.partial), "\r")
+		t.partial = nil
+		if err := t.parseLine(line, fsm); err != nil {
+			return err
+		}
+	}
+	return t.parseEOF(fsm)
+}
+
+// Writer returns an io.WriteCloser that passes everything written to it to ParseChunk.
+// Closing it calls Close. This allows copying a stream directly into the parser.
+// ex: io.Copy(parser.Writer(fsm), session)
+func (t *ParserOutput) Writer(fsm TextFSM) io.WriteCloser {
+	return &chunkWriter{output: t, fsm: fsm}
+}
+
+type chunkWriter struct {
+	output *ParserOutput
+	fsm    TextFSM
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	if err := w.output.ParseChunk(string(p), w.fsm); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *chunkWriter) Close() error {
+	return w.output.Close(w.fsm)
+}
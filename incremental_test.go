@@ -0,0 +1,145 @@
+package gotextfsm
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseChunk(t *testing.T) {
+	count := 0
+	for _, tc := range parseTestCases {
+		if tc.compile_err != nil || tc.reset != nil || tc.data1 != "" || (tc.eof != nil && !*tc.eof) {
+			continue
+		}
+		fsm := TextFSM{}
+		if err := fsm.ParseString(tc.template); err != nil {
+			t.Errorf("'%s' failed. TextFSM should be valid. But got error '%s'", tc.name, err.Error())
+			continue
+		}
+		expected := ParserOutput{}
+		experr := expected.ParseTextString(tc.data, fsm, true)
+		for _, size := range []int{1, 2, 3, 7, 1000} {
+			count++
+			out := ParserOutput{}
+			var err error
+			for i := 0; i < len(tc.data) && err == nil; i += size {
+				end := i + size
+				if end > len(tc.data) {
+					end = len(tc.data)
+				}
+				err = out.ParseChunk(tc.data[i:end], fsm)
+			}
+			if err == nil {
+				err = out.Close(fsm)
+			}
+			if (experr == nil) != (err == nil) {
+				t.Errorf("'%s' failed. Chunk size %d. Errors dont match ('%v', '%v')", tc.name, size, experr, err)
+				continue
+			}
+			if len(expected.Dict) != len(out.Dict) {
+				t.Errorf("'%s' failed. Chunk size %d. Expected %d records. Got %d records", tc.name, size, len(expected.Dict), len(out.Dict))
+				continue
+			}
+			for idx, exprec := range expected.Dict {
+				if msg := comparedicts(tc, exprec, out.Dict[idx], idx); msg != "" {
+					t.Errorf("Chunk size %d. %s", size, msg)
+					break
+				}
+			}
+		}
+	}
+	t.Logf("Executed %d test cases", count)
+}
+
+func TestParseChunkLines(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString("Value boo (.*)\n\nStart\n  ^$boo$$ -> Record\n"); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	out := ParserOutput{}
+	for _, chunk := range []string{"fir", "st\r", "\nsecond\r\nth", "ird\r\n", "", "last\r"} {
+		if err := out.ParseChunk(chunk, fsm); err != nil {
+			t.Fatalf("Expected no error. But found error '%s'", err)
+		}
+	}
+	if out.line_num != 3 || len(out.Dict) != 3 {
+		t.Errorf("Expected 3 lines and 3 records before Close. Got %d lines and %d records", out.line_num, len(out.Dict))
+	}
+	if err := out.Close(fsm); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	if out.line_num != 4 {
+		t.Errorf("Expected 4 lines. Got %d", out.line_num)
+	}
+	expected := []string{"first", "second", "third", "last"}
+	if len(out.Dict) != len(expected) {
+		t.Fatalf("Expected %d records. Got %v", len(expected), out.Dict)
+	}
+	for i, val := range expected {
+		if out.Dict[i]["boo"] != val {
+			t.Errorf("Record %d expected '%s'. Got '%v'", i, val, out.Dict[i]["boo"])
+		}
+	}
+	if err := out.ParseChunk("more", fsm); err == nil {
+		t.Errorf("Expected error after Close. But none found")
+	}
+	if err := out.Close(fsm); err == nil {
+		t.Errorf("Expected error on second Close. But none found")
+	}
+	out.Reset(fsm)
+	if err := out.ParseChunk("again\n", fsm); err != nil || out.line_num != 1 {
+		t.Errorf("Expected parsing to start again after Reset. Got error '%v', line %d", err, out.line_num)
+	}
+}
+
+func TestParseChunkWriter(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString("Value boo (\\d+)\n\nStart\n  ^$boo -> Record\n  ^end -> End\n"); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	out := ParserOutput{}
+	w := out.Writer(fsm)
+	if _, err := io.Copy(w, strings.NewReader("1\n2\nend\n3\n")); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	// Input after 'End' state is ignored.
+	if len(out.Dict) != 2 || out.Dict[0]["boo"] != "1" || out.Dict[1]["boo"] != "2" {
+		t.Errorf("Records dont match. Got %v", out.Dict)
+	}
+}
+
+func TestParseChunkLongLine(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString("Value boo (x+)\n\nStart\n  ^$boo$$ -> Record\n"); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	out := ParserOutput{}
+	w := out.Writer(fsm)
+	line := strings.Repeat("x", 100000)
+	for i := 0; i < len(line); i++ {
+		if _, err := w.Write([]byte{line[i]}); err != nil {
+			t.Fatalf("Expected no error. But found error '%s'", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	if len(out.Dict) != 1 || out.Dict[0]["boo"] != line {
+		t.Errorf("Records dont match. Got %d records", len(out.Dict))
+	}
+	if raceEnabled {
+		return
+	}
+	// The incomplete line is not copied on every chunk.
+	out.Reset(fsm)
+	allocs := testing.AllocsPerRun(1000, func() {
+		out.ParseChunk("x", fsm)
+	})
+	if allocs >= 0.1 {
+		t.Errorf("Expected no allocation per chunk of an incomplete line. Got %v", allocs)
+	}
+}
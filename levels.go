@@ -0,0 +1,210 @@
+package gotextfsm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Path of a level. Names of the levels separated by '.'. ex. 'Addresses.Secondary'
+var LEVEL_RE = regexp.MustCompile(`^\w+(\.\w+)*$`)
+
+// parseLevels collects the levels of the Values (See 'Level=' option). Parents of a level are levels too.
+// The name of a level (last part of the path) is the key of its records in the parent record
+// and the target of 'Record' operator. Hence, it must be unique and can not be the name of a Value.
+func (t *TextFSM) parseLevels() error {
+	t.levels = make(map[string]string)
+	t.level_paths = make([]string, 0)
+	for _, name := range t.value_names {
+		level := t.Values[name].Level
+		if level == "" {
+			continue
+		}
+		parts := strings.Split(level, ".")
+		for i := range parts {
+			path := strings.Join(parts[:i+1], ".")
+			if other, exists := t.levels[parts[i]]; exists {
+				if other != path {
+					return fmt.Errorf("Level name '%s' is used by '%s' and '%s'", parts[i], other, path)
+				}
+				continue
+			}
+			if _, exists := t.Values[parts[i]]; exists {
+				return fmt.Errorf("Level '%s' has the same name as a Value", parts[i])
+			}
+			t.levels[parts[i]] = path
+			t.level_paths = append(t.level_paths, path)
+		}
+	}
+	return nil
+}
+
+// levelValueNames returns the names of the Values of the level, in the order they were declared.
+// Empty level is the top level. Values of the tables (See 'Table=' option) are not in any level.
+func (t *TextFSM) levelValueNames(level string) []string {
+	if len(t.level_paths) == 0 && len(t.table_names) == 0 {
+		return t.value_names
+	}
+	names := make([]string, 0)
+	for _, name := range t.value_names {
+		if value := t.Values[name]; value.Level == level && value.Table == "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// childLevels returns the paths of the levels right below the level.
+func (t *TextFSM) childLevels(level string) []string {
+	children := make([]string, 0)
+	for _, path := range t.level_paths {
+		if parentLevel(path) == level {
+			children = append(children, path)
+		}
+	}
+	return children
+}
+
+// parentLevel returns the path of the parent of the level. Empty for the levels right below the top level.
+func parentLevel(path string) string {
+	if idx := strings.LastIndexByte(path, '.'); idx >= 0 {
+		return path[:idx]
+	}
+	return ""
+}
+
+// levelName returns the name of the level. (last part of the path)
+func levelName(path string) string {
+	return path[strings.LastIndexByte(path, '.')+1:]
+}
+
+// inLevel returns true if path is the level or a level below it.
+func inLevel(path string, level string) bool {
+	return level == "" || path == level || strings.HasPrefix(path, level+".")
+}
+
+// appendRecord adds the current record of the level to its parent record (or to the result for the top level)
+// if well formed. Records of the levels below it, that have any value set, are added first. Deepest first.
+func (t *ParserOutput) appendRecord(fsm TextFSM, level string) error {
+	for i := len(fsm.level_paths) - 1; i >= 0; i-- {
+		path := fsm.level_paths[i]
+		if path == level || !inLevel(path, level) || !t.levelPending(fsm, path) {
+			continue
+		}
+		if err := t.recordLevel(fsm, path); err != nil {
+			return err
+		}
+	}
+	return t.recordLevel(fsm, level)
+}
+
+// levelPending returns true if a value of the level is set or if records were added to the level since its last record.
+func (t *ParserOutput) levelPending(fsm TextFSM, level string) bool {
+	for _, name := range fsm.levelValueNames(level) {
+		value := t.values[name]
+		if empty, err := value.isEmptyValue(value.curval); err != nil || !empty {
+			return true
+		}
+	}
+	for _, child := range fsm.childLevels(level) {
+		if len(t.children[child]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// recordLevel builds the record of the level from its values and the records of the levels right below it.
+func (t *ParserOutput) recordLevel(fsm TextFSM, level string) error {
+	newmap, any_value, err := t.buildRecord(fsm.levelValueNames(level))
+	if err != nil {
+		return err
+	}
+	if newmap == nil {
+		t.clearLevel(fsm, level, false)
+		return nil
+	}
+	for _, child := range fsm.childLevels(level) {
+		records := t.children[child]
+		if records == nil {
+			records = make([]map[string]interface{}, 0)
+		}
+		newmap[levelName(child)] = records
+		if len(records) > 0 {
+			any_value = true
+		}
+	}
+	// If no Values in template or whole record is empty then don't output.
+	if any_value {
+		if level == "" {
+			t.Dict = append(t.Dict, newmap)
+		} else {
+			if t.children == nil {
+				t.children = make(map[string][]map[string]interface{})
+			}
+			t.children[level] = append(t.children[level], newmap)
+		}
+	}
+	t.clearLevel(fsm, level, false)
+	return nil
+}
+
+// buildRecord builds a record from the current values of the Values.
+//     Returns:
+//       The record. nil if the record is not well formed (See 'Required' option).
+//       true if any value of the record is not empty.
+func (t *ParserOutput) buildRecord(names []string) (map[string]interface{}, bool, error) {
+	// Check all the values before building the record. So that no record is built only to be thrown away.
+	for _, name := range names {
+		value := t.values[name]
+		ret, err := value.onAppendRecord()
+		if err != nil {
+			return nil, false, err
+		}
+		if ret == SKIP_RECORD {
+			return nil, false, nil
+		}
+	}
+	newmap := make(map[string]interface{}, len(names))
+	any_value := false
+	for _, name := range names {
+		value := t.values[name]
+		ret, _ := value.onAppendRecord()
+		switch ret {
+		case SKIP_VALUE:
+			newmap[name] = nil
+		case CONTINUE:
+			finalval, err := value.getFinalValue()
+			if err != nil {
+				return nil, false, err
+			}
+			newmap[name] = finalval
+			empty, err := value.isEmptyValue(finalval)
+			if err != nil {
+				return nil, false, err
+			}
+			if !empty {
+				any_value = true
+			}
+		}
+	}
+	return newmap, any_value, nil
+}
+
+// clearLevel clears the values of the level and of the levels below it, along with the records added to the levels below it.
+// Filldown values are cleared too if all is true.
+func (t *ParserOutput) clearLevel(fsm TextFSM, level string, all bool) {
+	for name, value := range t.values {
+		if value.Table != "" || !inLevel(value.Level, level) {
+			continue
+		}
+		value.clearValue(all)
+		// value is a copy of the value in the map. Store the modified copy back.
+		t.values[name] = value
+	}
+	for path := range t.children {
+		if path != level && inLevel(path, level) {
+			delete(t.children, path)
+		}
+	}
+}
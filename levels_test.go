@@ -0,0 +1,266 @@
+package gotextfsm
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const levelsTemplate = `Value Required Interface (\S+)
+Value Level=Addresses,Required Address (\d+\.\d+\.\d+\.\d+)
+Value Level=Addresses Mask (\d+)
+Value Level=Addresses.Tags Tag (\S+)
+Value Level=Helpers Helper (\S+)
+
+Start
+  ^interface -> Continue.Record
+  ^interface ${Interface}
+  ^ ip address -> Continue.Record Addresses
+  ^ ip address ${Address}/${Mask}
+  ^  tag ${Tag} -> Record Tags
+  ^ ip helper ${Helper} -> Record Helpers
+`
+
+const levelsData = `interface Gi0/1
+ ip address 10.0.0.1/24
+  tag mgmt
+  tag voice
+ ip address 10.0.1.1/24
+ ip helper 10.9.9.9
+interface Gi0/2
+ ip helper 10.9.9.9
+  tag orphan
+interface Gi0/3
+`
+
+func TestLevels(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString(levelsTemplate); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	out := ParserOutput{}
+	if err := out.ParseTextString(levelsData, fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	none := make([]map[string]interface{}, 0)
+	expected := []map[string]interface{}{
+		{
+			"Interface": "Gi0/1",
+			"Addresses": []map[string]interface{}{
+				{"Address": "10.0.0.1", "Mask": "24", "Tags": []map[string]interface{}{{"Tag": "mgmt"}, {"Tag": "voice"}}},
+				{"Address": "10.0.1.1", "Mask": "24", "Tags": none},
+			},
+			"Helpers": []map[string]interface{}{{"Helper": "10.9.9.9"}},
+		},
+		{
+			// The tag without an address is dropped, as Address is Required.
+			"Interface": "Gi0/2",
+			"Addresses": none,
+			"Helpers":   []map[string]interface{}{{"Helper": "10.9.9.9"}},
+		},
+		{"Interface": "Gi0/3", "Addresses": none, "Helpers": none},
+	}
+	if !reflect.DeepEqual(out.Dict, expected) {
+		t.Errorf("Records dont match (%v, %v)", expected, out.Dict)
+	}
+}
+
+func TestLevelsImplicitRecord(t *testing.T) {
+	// Records of the levels below are added when the parent is recorded, if any of their values is set.
+	template := `Value Filldown Device (\S+)
+Value Required Interface (\S+)
+Value Level=Neighbors Neighbor (\S+)
+Value Level=Neighbors,Filldown Port (\S+)
+
+Start
+  ^device ${Device}
+  ^interface -> Continue.Record
+  ^interface ${Interface}
+  ^ port ${Port}
+  ^ neighbor ${Neighbor}
+`
+	fsm := TextFSM{}
+	if err := fsm.ParseString(template); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	data := "device sw1\ninterface Gi0/1\n port 1\n neighbor sw2\ninterface Gi0/2\n neighbor sw3\ninterface Gi0/3\n"
+	out := ParserOutput{}
+	if err := out.ParseTextString(data, fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	expected := []map[string]interface{}{
+		{"Device": "sw1", "Interface": "Gi0/1", "Neighbors": []map[string]interface{}{{"Neighbor": "sw2", "Port": "1"}}},
+		{"Device": "sw1", "Interface": "Gi0/2", "Neighbors": []map[string]interface{}{{"Neighbor": "sw3", "Port": "1"}}},
+		{"Device": "sw1", "Interface": "Gi0/3", "Neighbors": make([]map[string]interface{}, 0)},
+	}
+	if !reflect.DeepEqual(out.Dict, expected) {
+		t.Errorf("Records dont match (%v, %v)", expected, out.Dict)
+	}
+}
+
+func TestLevelsTemplateErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		template string
+		err      string
+	}{
+		{
+			name:     "Invalid level",
+			template: "Value Level=a..b Foo (\\S+)\n\nStart\n  ^${Foo}\n",
+			err:      "Line 1: Invalid level 'a..b'",
+		},
+		{
+			name:     "Duplicate level option",
+			template: "Value Level=a,Level=b Foo (\\S+)\n\nStart\n  ^${Foo}\n",
+			err:      "1 Line: Duplicate option Level",
+		},
+		{
+			name:     "Fillup with level",
+			template: "Value Level=a,Fillup Foo (\\S+)\n\nStart\n  ^${Foo}\n",
+			err:      "Line 1: Option Fillup can not be used with Level",
+		},
+		{
+			name:     "Level name used twice",
+			template: "Value Level=a.c Foo (\\S+)\nValue Level=b.c Bar (\\S+)\n\nStart\n  ^${Foo}\n",
+			err:      "Level name 'c' is used by 'a.c' and 'b.c'",
+		},
+		{
+			name:     "Level with the name of a Value",
+			template: "Value Level=Bar Foo (\\S+)\nValue Bar (\\S+)\n\nStart\n  ^${Foo}\n",
+			err:      "Level 'Bar' has the same name as a Value",
+		},
+		{
+			name:     "State with the name of a level",
+			template: "Value Level=a Foo (\\S+)\n\nStart\n  ^${Foo}\n\na\n  ^x\n",
+			err:      "6 Line: state 'a' has the same name as a level",
+		},
+		{
+			name:     "Level without Record",
+			template: "Value Level=a Foo (\\S+)\n\nStart\n  ^${Foo} -> Next.Clear a Start\n",
+			err:      "Level or table 'a' given without 'Record' operator. Line: 4.",
+		},
+		{
+			name:     "Unknown level",
+			template: "Value Level=a Foo (\\S+)\n\nStart\n  ^${Foo} -> Record b Start\n",
+			err:      "Level or table 'b' not found. Line: 4.",
+		},
+	}
+	for _, tc := range cases {
+		fsm := TextFSM{}
+		err := fsm.ParseString(tc.template)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("'%s' failed. Errors dont match ('%s', '%v')", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestLevelsRuleTarget(t *testing.T) {
+	template := "Value Foo (\\S+)\nValue Level=Bars Bar (\\S+)\n\nStart\n" +
+		"  ^a ${Bar} -> Continue.Record Bars\n  ^b ${Bar} -> Next.Record Bars Other\n  ^c ${Foo} -> Record Other\n\nOther\n  ^x\n"
+	fsm := TextFSM{}
+	if err := fsm.ParseString(template); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	rules := fsm.States["Start"].rules
+	expected := []struct {
+		target   string
+		newState string
+		str      string
+	}{
+		{"Bars", "", " ^a ${Bar} -> Continue.Record Bars"},
+		{"Bars", "Other", " ^b ${Bar} -> Next.Record Bars Other"},
+		{"", "Other", " ^c ${Foo} -> Record Other"},
+	}
+	for i, exp := range expected {
+		rule := rules[i]
+		if rule.RecordTarget != exp.target || rule.NewState != exp.newState || rule.String() != exp.str {
+			t.Errorf("Rule %d failed. Rules dont match ('%s', '%s', '%s') ('%s', '%s', '%s')", i, exp.target, exp.newState, exp.str,
+				rule.RecordTarget, rule.NewState, rule.String())
+		}
+	}
+}
+
+func TestLevelsEncodeAndSnapshot(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString(levelsTemplate); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	lines := strings.SplitAfter(levelsData, "\n")
+	// Snapshot in the middle of the records of a level.
+	first := ParserOutput{}
+	if err := first.ParseTextString(strings.Join(lines[:8], ""), fsm, false); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	data, err := first.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed with error '%s'", err)
+	}
+	restored := ParserOutput{}
+	if err := restored.Restore(data, fsm); err != nil {
+		t.Fatalf("Restore failed with error '%s'", err)
+	}
+	if err := restored.ParseTextString(strings.Join(lines[8:], ""), fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	whole := ParserOutput{}
+	if err := whole.ParseTextString(levelsData, fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	if !reflect.DeepEqual(restored.Dict, whole.Dict) {
+		t.Errorf("Records dont match (%v, %v)", whole.Dict, restored.Dict)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, fsm, whole.Dict[1:2]); err != nil {
+		t.Fatalf("WriteJSON failed with error '%s'", err)
+	}
+	expected := `[
+  {
+    "Interface": "Gi0/2",
+    "Addresses": [],
+    "Helpers": [
+      {
+        "Helper": "10.9.9.9"
+      }
+    ]
+  }
+]
+`
+	if buf.String() != expected {
+		t.Errorf("JSON dont match ('%s', '%s')", expected, buf.String())
+	}
+	buf.Reset()
+	if err := WriteYAML(&buf, fsm, whole.Dict[:1]); err != nil {
+		t.Fatalf("WriteYAML failed with error '%s'", err)
+	}
+	expected = `- Interface: "Gi0/1"
+  Addresses:
+    - Address: "10.0.0.1"
+      Mask: "24"
+      Tags:
+        - Tag: "mgmt"
+        - Tag: "voice"
+    - Address: "10.0.1.1"
+      Mask: "24"
+      Tags: []
+  Helpers:
+    - Helper: "10.9.9.9"
+`
+	if buf.String() != expected {
+		t.Errorf("YAML dont match ('%s', '%s')", expected, buf.String())
+	}
+	parsed, err := parseYAML(buf.String())
+	if err != nil {
+		t.Errorf("YAML can not be read back. Error '%s'", err)
+	} else if !reflect.DeepEqual(parsed, []interface{}{genericValue(whole.Dict[:1])}[0]) {
+		t.Errorf("YAML read back dont match (%v, %v)", genericValue(whole.Dict[:1]), parsed)
+	}
+	buf.Reset()
+	if err := WriteCSV(&buf, fsm, whole.Dict, CSVOptions{}); err != nil {
+		t.Fatalf("WriteCSV failed with error '%s'", err)
+	}
+	if buf.String() != "Interface\nGi0/1\nGi0/2\nGi0/3\n" {
+		t.Errorf("CSV dont match ('%s')", buf.String())
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !race
+
+package gotextfsm
+
+// raceEnabled is true when the tests run with the race detector, which makes extra allocations.
+const raceEnabled = false
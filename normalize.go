@@ -0,0 +1,124 @@
+package gotextfsm
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Normalizer cleans up an input line before it is passed through FSM.
+// Returns the cleaned up line and false if the line must be dropped altogether.
+// Dropped lines still count for the line numbers.
+//
+// Normalizers are set in ParserOutput.Normalizers and applied in that order. Any func of this type can be used.
+type Normalizer func(line string) (string, bool)
+
+// DefaultNormalizers returns the built-in normalizers, in the order they are meant to be applied to captured CLI sessions.
+func DefaultNormalizers() []Normalizer {
+	return []Normalizer{StripNUL, StripANSI, NormalizeCR, ApplyBackspaces, StripPager}
+}
+
+// StripNUL removes NUL characters. (ex. padding of telnet sessions)
+func StripNUL(line string) (string, bool) {
+	return strings.ReplaceAll(line, "\x00", ""), true
+}
+
+// ANSI escape sequences: CSI (ex. colours "\x1b[1;31m", cursor movement "\x1b[K"), OSC (ex. window title) and
+// two character sequences.
+var ANSI_RE = regexp.MustCompile(`\x1b\[[0-9:;<=>?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// Cursor back sequence. ex. "\x1b[42D"
+var CURSOR_BACK_RE = regexp.MustCompile(`^\x1b\[(\d*)D$`)
+
+// StripANSI removes ANSI escape sequences of terminals.
+// Cursor back sequences are turned into as many backspaces instead (See ApplyBackspaces). Pagers use them to erase their prompt.
+func StripANSI(line string) (string, bool) {
+	if strings.IndexByte(line, '\x1b') < 0 {
+		return line, true
+	}
+	return ANSI_RE.ReplaceAllStringFunc(line, func(seq string) string {
+		m := CURSOR_BACK_RE.FindStringSubmatch(seq)
+		if m == nil {
+			return ""
+		}
+		count := 1
+		if m[1] != "" {
+			count, _ = strconv.Atoi(m[1])
+		}
+		// Terminals move by one column for a count of 0, as for no count.
+		if count == 0 {
+			count = 1
+		}
+		// Can not go back more than the length of the line.
+		if count > len(line) || count < 0 {
+			count = len(line)
+		}
+		return strings.Repeat("\b", count)
+	}), true
+}
+
+// NormalizeCR removes the trailing carriage returns (of "\r\n" line endings).
+// A carriage return inside the line moves back to the start of the line, like a terminal does.
+// The text that follows it overwrites the text before it. (ex. "---(more)---\r            \rge-0/0/0 up" is "ge-0/0/0 up ")
+func NormalizeCR(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r")
+	if strings.IndexByte(line, '\r') < 0 {
+		return line, true
+	}
+	segments := strings.Split(line, "\r")
+	output := []rune(segments[0])
+	for _, segment := range segments[1:] {
+		for i, ch := range []rune(segment) {
+			if i < len(output) {
+				output[i] = ch
+			} else {
+				output = append(output, ch)
+			}
+		}
+	}
+	return string(output), true
+}
+
+// ApplyBackspaces removes each backspace along with the character before it.
+// Pagers erase their prompt with backspaces. (ex. " --More-- \b\b\b\b\b\b\b\b\b\b          \b\b\b\b\b\b\b\b\b\b")
+func ApplyBackspaces(line string) (string, bool) {
+	if strings.IndexByte(line, '\b') < 0 {
+		return line, true
+	}
+	output := make([]rune, 0, len(line))
+	for _, ch := range line {
+		if ch == '\b' {
+			if len(output) > 0 {
+				output = output[:len(output)-1]
+			}
+			continue
+		}
+		output = append(output, ch)
+	}
+	return string(output), true
+}
+
+// Prompts of pagers. ex. " --More-- " (Cisco), "  ---- More ----" (Huawei), "---(more 25%)---" (Juniper), "<--- More --->"
+// Only the spaces of the prompt itself are included. Not the indentation of the text that follows it.
+var PAGER_RE = regexp.MustCompile(`[ ]*(?:<-{2,}|-{2,}\(?)[ ]*[Mm]ore(?:[ ]+\d+%)?[ ]*(?:-{2,}>|\)?-{2,})[ ]?`)
+
+// StripPager removes the prompts of pagers that are left in the line.
+// The line is dropped if nothing but the prompt is in it. So that it does not look like a blank line to the template.
+func StripPager(line string) (string, bool) {
+	if !PAGER_RE.MatchString(line) {
+		return line, true
+	}
+	output := PAGER_RE.ReplaceAllString(line, "")
+	return output, output != ""
+}
+
+// normalize passes the line through the Normalizers. Returns false if the line must be dropped.
+func (t *ParserOutput) normalize(line string) (string, bool) {
+	for _, normalizer := range t.Normalizers {
+		var keep bool
+		if line, keep = normalizer(line); !keep {
+			return "", false
+		}
+	}
+	return line, true
+}
@@ -0,0 +1,143 @@
+package gotextfsm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizers(t *testing.T) {
+	testcases := []struct {
+		name       string
+		normalizer Normalizer
+		input      string
+		output     string
+		keep       bool
+	}{
+		{name: "NUL", normalizer: StripNUL, input: "Gi0/1\x00\x00 up", output: "Gi0/1 up", keep: true},
+		{name: "ANSI colours", normalizer: StripANSI, input: "\x1b[1;32mUP\x1b[0m eth0", output: "UP eth0", keep: true},
+		{name: "ANSI erase line", normalizer: StripANSI, input: "\x1b[KRouter#\x1b[?25h", output: "Router#", keep: true},
+		{name: "ANSI window title", normalizer: StripANSI, input: "\x1b]0;user@host: ~\x07$ ls", output: "$ ls", keep: true},
+		{name: "ANSI cursor back", normalizer: StripANSI, input: "ab\x1b[2Dc\x1b[D", output: "ab\b\bc\b", keep: true},
+		{name: "ANSI cursor back by 0", normalizer: StripANSI, input: "ab\x1b[0Dc\x1b[00D", output: "ab\bc\b", keep: true},
+		{name: "ANSI cursor back too far", normalizer: StripANSI, input: "ab\x1b[99999999999999999999D", output: "ab" + strings.Repeat("\b", 25), keep: true},
+		{name: "ANSI none", normalizer: StripANSI, input: "[1;32m", output: "[1;32m", keep: true},
+		{name: "CRLF", normalizer: NormalizeCR, input: "eth0 up\r\r", output: "eth0 up", keep: true},
+		{name: "CR overwrite", normalizer: NormalizeCR, input: "---(more)---\r            \rge-0/0/0 up", output: "ge-0/0/0 up ", keep: true},
+		{name: "CR longer", normalizer: NormalizeCR, input: "abc\rdefgh", output: "defgh", keep: true},
+		{name: "Backspaces", normalizer: ApplyBackspaces, input: "Gi0/1 dwon\b\b\bown", output: "Gi0/1 down", keep: true},
+		{name: "Backspaces at start", normalizer: ApplyBackspaces, input: "\b\bup", output: "up", keep: true},
+		{name: "Cisco pager", normalizer: ApplyBackspaces, input: " --More-- \b\b\b\b\b\b\b\b\b\b          \b\b\b\b\b\b\b\b\b\b  Hardware is Gigabit", output: "  Hardware is Gigabit", keep: true},
+		{name: "Pager left", normalizer: StripPager, input: " --More--   Hardware is Gigabit", output: "  Hardware is Gigabit", keep: true},
+		{name: "Pager only", normalizer: StripPager, input: "  ---- More ----", output: "", keep: false},
+		{name: "Pager percent", normalizer: StripPager, input: "---(more 25%)---", output: "", keep: false},
+		{name: "Pager arrows", normalizer: StripPager, input: "<--- More --->eth0 up", output: "eth0 up", keep: true},
+		{name: "Not a pager", normalizer: StripPager, input: "Description: some-more-words", output: "Description: some-more-words", keep: true},
+		{name: "Blank line", normalizer: StripPager, input: "", output: "", keep: true},
+	}
+	for _, tc := range testcases {
+		output, keep := tc.normalizer(tc.input)
+		if output != tc.output || keep != tc.keep {
+			t.Errorf("'%s' failed. Outputs dont match ('%q', %v) ('%q', %v)", tc.name, tc.output, tc.keep, output, keep)
+		}
+	}
+}
+
+const normalizeTemplate = `Value Interface (\S+)
+Value Status (up|down)
+Value Description (.*\S)
+
+Start
+  ^${Interface} is ${Status}$$
+  ^\s+Description: ${Description}\s*$$
+  ^\s*$$ -> Record
+`
+
+func TestParseMessyCaptures(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString(normalizeTemplate); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	clean := "GigabitEthernet0/1 is up\n  Description: Uplink to core\n\nGigabitEthernet0/2 is down\n  Description: Spare port\n\n"
+	expected := ParserOutput{}
+	if err := expected.ParseTextString(clean, fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	if len(expected.Dict) != 2 {
+		t.Fatalf("Expected 2 records. Got %v", expected.Dict)
+	}
+	captures := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "Cisco, CRLF and pager erased with backspaces",
+			data: "GigabitEthernet0/1 is up\r\n  Description: Uplink to core\r\n\r\n --More-- \b\b\b\b\b\b\b\b\b\b          \b\b\b\b\b\b\b\b\b\bGigabitEthernet0/2 is down\r\n" +
+				"  Description: Spare port\r\n\r\n",
+		},
+		{
+			name: "Juniper, pager erased with carriage returns",
+			data: "GigabitEthernet0/1 is up\n  Description: Uplink to core\n\n---(more 50%)---\r                \rGigabitEthernet0/2 is down\n  Description: Spare port\n\n",
+		},
+		{
+			name: "Huawei, pager erased with cursor movement",
+			data: "GigabitEthernet0/1 is up\n  Description: Uplink to core\n\n  ---- More ----\x1b[42D                                          \x1b[42DGigabitEthernet0/2 is down\n  Description: Spare port\n\n",
+		},
+		{
+			name: "Colours and NUL padding",
+			data: "\x1b[1mGigabitEthernet0/1\x1b[0m is \x1b[32mup\x1b[0m\x00\x00\n  Description: Uplink to core\x00\n\n\x1b[1mGigabitEthernet0/2\x1b[0m is \x1b[31mdown\x1b[0m\n  Description: Spare port\n\x00\n",
+		},
+	}
+	for _, tc := range captures {
+		raw := ParserOutput{}
+		if err := raw.ParseTextString(tc.data, fsm, true); err != nil {
+			t.Errorf("'%s' failed. Parsing failed with error '%s'", tc.name, err)
+		} else if reflect.DeepEqual(raw.Dict, expected.Dict) {
+			t.Errorf("'%s' failed. Capture is not messy enough to test the normalizers", tc.name)
+		}
+		out := ParserOutput{Normalizers: DefaultNormalizers()}
+		if err := out.ParseTextString(tc.data, fsm, true); err != nil {
+			t.Errorf("'%s' failed. Parsing failed with error '%s'", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(out.Dict, expected.Dict) {
+			t.Errorf("'%s' failed. Records dont match\n%v\n%v", tc.name, expected.Dict, out.Dict)
+		}
+		// Same when the capture arrives in chunks
+		chunked := ParserOutput{Normalizers: DefaultNormalizers()}
+		for i := 0; i < len(tc.data); i += 7 {
+			end := i + 7
+			if end > len(tc.data) {
+				end = len(tc.data)
+			}
+			chunked.ParseChunk(tc.data[i:end], fsm)
+		}
+		if err := chunked.Close(fsm); err != nil || !reflect.DeepEqual(chunked.Dict, expected.Dict) {
+			t.Errorf("'%s' failed. Records of chunks dont match\n%v\n%v. Error: %v", tc.name, expected.Dict, chunked.Dict, err)
+		}
+	}
+}
+
+func TestCustomNormalizer(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString(normalizeTemplate); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	// Drop the log messages interleaved with the output. Dropped lines still count for the line numbers.
+	dropLogs := func(line string) (string, bool) {
+		return line, !strings.HasPrefix(line, "%")
+	}
+	data := "GigabitEthernet0/1 is up\n%LINK-3-UPDOWN: Interface Gi0/3, changed state to up\n  Desc: Uplink\n\n"
+	out := ParserOutput{Normalizers: []Normalizer{dropLogs}, CollectUnmatched: true}
+	if err := out.ParseTextString(data, fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	unmatched := []UnmatchedLine{{Line: 3, State: "Start", Input: "  Desc: Uplink"}}
+	if !reflect.DeepEqual(out.Unmatched, unmatched) {
+		t.Errorf("Unmatched lines dont match (%v, %v)", unmatched, out.Unmatched)
+	}
+	expected := []map[string]interface{}{{"Interface": "GigabitEthernet0/1", "Status": "up", "Description": ""}}
+	if !reflect.DeepEqual(out.Dict, expected) {
+		t.Errorf("Records dont match (%v, %v)", expected, out.Dict)
+	}
+}
@@ -3,7 +3,6 @@ package gotextfsm
 import (
 	"bufio"
 	"fmt"
-	"regexp"
 	"strings"
 )
 
@@ -11,16 +10,71 @@ import (
 // Each record is represented as map of (name,value)
 //
 // Note that type of value is interface{}. But the concrete type is either 'string' or '[]string'
+// (or 'map[string]string' and '[]map[string]string' for values with nested match groups).
+// Records of the levels below (See 'Level=' option of Values) are held as '[]map[string]interface{}' under the name of the level.
+//
+// Records of the Values declared with 'Table=' option are added to Tables by the name of the table, instead of Dict.
+//
+// The state of the parsing (current values, Filldown values etc) is kept in ParserOutput and not in TextFSM.
+// Hence, a single TextFSM can be shared by multiple ParserOutputs, even across goroutines.
+//
+// By default, an 'Error' action of the template stops the parsing and returns an error.
+// If Lenient is set, the 'Error' action is added to Diagnostics instead, the record being built is cleared
+// and parsing continues with the next line.
+//
+// Input lines that match no rule of the current state are ignored. If CollectUnmatched is set, they are added to Unmatched.
+// If the current state is one of StrictStates, such a line stops the parsing with an error (or is added to Diagnostics if Lenient).
+//
+// Each input line is cleaned up by the Normalizers (if any) before it is passed through FSM. See DefaultNormalizers.
+//
+// States called with 'Call' are kept in a stack, the depth of which is limited by MaxCallDepth.
 type ParserOutput struct {
-	Dict           []map[string]interface{}
-	line_num       int
-	cur_state_name string
+	Dict             []map[string]interface{}
+	Tables           map[string][]map[string]interface{}
+	Lenient          bool
+	Diagnostics      []Diagnostic
+	CollectUnmatched bool
+	Unmatched        []UnmatchedLine
+	StrictStates     []string
+	Normalizers      []Normalizer
+	MaxCallDepth     int // Maximum depth of the states called with 'Call'. DEFAULT_MAX_CALL_DEPTH if 0.
+	line_num         int
+	cur_state_name   string
+	values           map[string]TextFSMValue
+	children         map[string][]map[string]interface{} // Records of the levels added since the last record of their parents
+	stack            []string                            // States to return to from the called states. See 'Call'.
+	candidates       []bool
+	partial          []byte // Incomplete line at the end of the chunks. See ParseChunk.
+	closed           bool
 }
 
 func (t *ParserOutput) Reset(fsm TextFSM) {
-	t.clearRecord(fsm, true)
+	t.values = nil
+	t.children = nil
+	t.stack = nil
+	t.initValues(fsm)
 	t.cur_state_name = "Start"
 	t.Dict = make([]map[string]interface{}, 0)
+	t.Tables = nil
+	t.initTables(fsm)
+	t.Diagnostics = nil
+	t.Unmatched = nil
+	t.line_num = 0
+	t.partial = nil
+	t.closed = false
+}
+
+// initValues creates the private copy of the Values of the fsm that holds the state of parsing.
+// Does nothing if the copy already exists.
+func (t *ParserOutput) initValues(fsm TextFSM) {
+	if t.values != nil {
+		return
+	}
+	t.values = make(map[string]TextFSMValue)
+	for name, value := range fsm.Values {
+		value.clearValue(true)
+		t.values[name] = value
+	}
 }
 
 // ParseTextString passes CLI output (provided as string) through FSM and
@@ -39,37 +93,76 @@ func (t *ParserOutput) ParseTextReader(reader *strings.Reader, fsm TextFSM, eof
 	return t.ParseTextScanner(bufio.NewScanner(reader), fsm, eof)
 }
 
-func (t *ParserOutput) ParseTextScanner(scanner *bufio.Scanner, fsm TextFSM, eof bool) error {
-	t.line_num = 0
+// ParseTextScanner passes the lines read from the scanner through FSM.
+// Line numbers continue from the previous call on the same ParserOutput. They start again only on Reset.
+// See ParseTextString for the arguments.
+func (t *ParserOutput) ParseTextScanner(scanner *bufio.Scanner, fsm TextFSM, eof bool) (err error) {
+	defer t.recoverInternalError("ParseText", &err)
+	t.start(fsm)
+	for scanner.Scan() {
+		if err := t.parseLine(scanner.Text(), fsm); err != nil {
+			return err
+		}
+		if t.finished() {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%d Line: Scanner Error %s", t.line_num+1, err)
+	}
+	if eof {
+		return t.parseEOF(fsm)
+	}
+	return nil
+}
+
+// start prepares ParserOutput for parsing, if it is not already done.
+func (t *ParserOutput) start(fsm TextFSM) {
 	if t.cur_state_name == "" {
 		t.cur_state_name = "Start"
 	}
 	if t.Dict == nil {
 		t.Dict = make([]map[string]interface{}, 0)
 	}
-	for {
-		t.line_num++
-		line_present := scanner.Scan()
-		if !line_present {
-			if err := scanner.Err(); err != nil {
-				return fmt.Errorf("%d Line: Scanner Error %s", t.line_num, err)
-			}
-			break
-		}
-		line := scanner.Text()
-		err := t.checkLine(line, fsm)
-		if err != nil {
-			return err
-		}
-		if t.cur_state_name == "End" || t.cur_state_name == "EOF" {
-			break
+	t.initTables(fsm)
+	t.initValues(fsm)
+}
+
+// parseLine passes one line of input through FSM.
+// The line is ignored if FSM has already reached 'End' or 'EOF' state.
+func (t *ParserOutput) parseLine(line string, fsm TextFSM) error {
+	if t.finished() {
+		return nil
+	}
+	t.line_num++
+	if t.Normalizers != nil {
+		var keep bool
+		if line, keep = t.normalize(line); !keep {
+			return nil
 		}
 	}
+	return t.checkLine(line, fsm)
+}
+
+// finished returns true if FSM reached 'End' or 'EOF' state and no more input is processed.
+func (t *ParserOutput) finished() bool {
+	return t.cur_state_name == "End" || t.cur_state_name == "EOF"
+}
+
+// parseEOF handles the end of input.
+func (t *ParserOutput) parseEOF(fsm TextFSM) error {
 	_, eof_exists := fsm.States["EOF"]
-	if t.cur_state_name != "End" && (!eof_exists) && eof {
-		// Implicit EOF performs Next.Record operation.
+	if t.cur_state_name != "End" && (!eof_exists) {
+		// Implicit EOF performs Next.Record operation. On all the tables.
 		// Suppressed if Null EOF state is instantiated.
-		t.appendRecord(fsm)
+		if err := t.appendRecord(fsm, ""); err != nil {
+			return err
+		}
+		for _, table := range fsm.table_names {
+			if err := t.recordTable(fsm, table); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -83,8 +176,9 @@ func (t *ParserOutput) ParseTextScanner(scanner *bufio.Scanner, fsm TextFSM, eof
 //     Eg.
 //     Value List ((?P<name>\w+)\s+(?P<age>\d+)) would create results like:
 //         [{'name': 'Bob', 'age': 32}]
-//     Do not give nested groups the same name as other values in the template.
-//     Nested regexps more than 2 levels are not supported currently
+//     Nested groups can have the same name as other values in the template. Each rule resolves the groups
+//     of its values (rule.groups) and the nested groups within them (rule.nested) separately, so they do not clash.
+//     Nested groups can not contain named groups. Such templates are rejected while parsing the template.
 //     Args:
 //       line: A string, the current input line.
 //		 fsm: TextFSM Object
@@ -93,49 +187,80 @@ func (t *ParserOutput) checkLine(line string, fsm TextFSM) error {
 	state, exists := fsm.States[t.cur_state_name]
 	if !exists {
 		// Should never happen for a proper TextFSM
-		panic(fmt.Sprintf("Unknown State %s", t.cur_state_name))
-	}
-	for _, rule := range state.rules {
-		varmap := GetNamedMatches(regexp.MustCompile(rule.Regex), line)
-		if varmap != nil {
-			// fmt.Printf("Line '%s'. Regex: '%s' varmap: '%v'\n", line, rule.Regex, varmap)
-			for key, val := range varmap {
-				valobj, exists := fsm.Values[key]
+		return fmt.Errorf("%d Line: Unknown State %s", t.line_num, t.cur_state_name)
+	}
+	var candidates []bool
+	if state.matcher != nil {
+		// Reuse the buffer across lines.
+		if cap(t.candidates) < len(state.rules) {
+			t.candidates = make([]bool, len(state.rules))
+		}
+		candidates = t.candidates[:len(state.rules)]
+		state.matcher.find(line, candidates)
+	}
+	matched := false
+	for i, rule := range state.rules {
+		if candidates != nil && !candidates[i] {
+			continue
+		}
+		if !rule.mayMatch(line) {
+			continue
+		}
+		loc := rule.regex.FindStringSubmatchIndex(line)
+		if loc != nil {
+			matched = true
+			for _, key := range rule.values {
+				valobj, exists := t.values[key]
 				if !exists {
-					// This may happen in case of nested match groups.
-					// There will be no TextFSMValue with the names inside the the nested match groups.
 					continue
 				}
-				if strings.Contains(valobj.Regex, "(?P") {
-					valobj.processMapValue(varmap)
+				var err error
+				if valobj.isMap() {
+					newmap := make(map[string]string, len(valobj.group_names))
+					for _, name := range valobj.group_names {
+						newmap[name] = rule.nestedSubmatch(line, loc, key, name)
+					}
+					err = valobj.processMapValue(newmap)
 				} else {
-					valobj.processScalarValue(val)
+					err = valobj.processScalarValue(rule.submatch(line, loc, key))
+				}
+				if err != nil {
+					return fmt.Errorf("%d Line: %s", t.line_num, err)
 				}
 				if FindIndex(valobj.Options, "Fillup") >= 0 && valobj.curval != nil && t.Dict != nil {
 					for i := len(t.Dict) - 1; i >= 0; i-- {
-						if valobj.isEmptyValue(t.Dict[i][key]) {
+						empty, err := valobj.isEmptyValue(t.Dict[i][key])
+						if err != nil {
+							return fmt.Errorf("%d Line: %s", t.line_num, err)
+						}
+						if empty {
 							t.Dict[i][key] = valobj.curval
 						} else {
 							break
 						}
 					}
 				}
-				// For some reason, modifying curval in valobj using processValue is not reflecting.
-				// Setting it back to fsm.Values works. Need to understand this further
-				fsm.Values[key] = valobj
+				// valobj is a copy of the value in the map. Store the modified copy back.
+				t.values[key] = valobj
 			}
 			output, err := t.handleOperations(rule, fsm, line)
 			if err != nil {
 				return err
 			}
 			if output {
-				if rule.NewState != "" {
-					t.cur_state_name = rule.NewState
+				// NewState of 'Error' action is the message. Not a state.
+				if rule.NewState != "" && rule.LineOp != "Error" {
+					if err := t.changeState(rule); err != nil {
+						return err
+					}
 				}
 				break
 			}
 		}
 	}
+	if !matched {
+		return t.noMatch(line)
+	}
 	// fmt.Printf("After Line: '%s: ' current state: '%s'\n", line, t.cur_state_name)
 
 	// for name, varobj := range fsm.Values {
@@ -145,32 +270,6 @@ func (t *ParserOutput) checkLine(line string, fsm TextFSM) error {
 	return nil
 }
 
-// appendRecord adds current record to result if well formed.
-func (t *ParserOutput) appendRecord(fsm TextFSM) {
-	newmap := make(map[string]interface{})
-	any_value := false
-	for name, value := range fsm.Values {
-		ret := value.onAppendRecord()
-		switch ret {
-		case SKIP_RECORD:
-			t.clearRecord(fsm, false)
-			return
-		case SKIP_VALUE:
-			newmap[name] = nil
-		case CONTINUE:
-			newmap[name] = value.getFinalValue()
-			if !value.isEmptyValue(newmap[name]) {
-				any_value = true
-			}
-		}
-	}
-	// If no Values in template or whole record is empty then don't output.
-	if any_value {
-		t.Dict = append(t.Dict, newmap)
-	}
-	t.clearRecord(fsm, false)
-}
-
 // handleOperation handles Operators on the data record.
 //
 // Operators come in two parts and are a '.' separated pair:
@@ -182,7 +281,8 @@ func (t *ParserOutput) appendRecord(fsm TextFSM) {
 //
 //   Operators that affect the record being built for output (record_op).
 // 	'NoRecord'  Does nothing (default)
-// 	'Record'    Adds the current record to the result.
+// 	'Record'    Adds the current record to the result. Or to the parent record, if a level is given.
+// 	            Or to the table, if a table is given.
 // 	'Clear'     Clears non-Filldown data from the record.
 // 	'Clearall'  Clears all data from the record.
 //
@@ -191,10 +291,18 @@ func (t *ParserOutput) appendRecord(fsm TextFSM) {
 //   line: A string, the current input line.
 // Returns:
 //   True if state machine should restart state with new line.
-//   error: If Error state is encountered.
+//   error: If Error state is encountered (Unless ParserOutput is Lenient).
 func (t *ParserOutput) handleOperations(rule TextFSMRule, fsm TextFSM, line string) (output bool, err error) {
 	if rule.RecordOp == "Record" {
-		t.appendRecord(fsm)
+		var err error
+		if rule.table != "" {
+			err = t.recordTable(fsm, rule.table)
+		} else {
+			err = t.appendRecord(fsm, rule.level)
+		}
+		if err != nil {
+			return false, fmt.Errorf("%d Line: %s", t.line_num, err)
+		}
 	}
 	if rule.RecordOp == "Clear" {
 		t.clearRecord(fsm, false)
@@ -203,6 +311,11 @@ func (t *ParserOutput) handleOperations(rule TextFSMRule, fsm TextFSM, line stri
 		t.clearRecord(fsm, true)
 	}
 	if rule.LineOp == "Error" {
+		if t.Lenient {
+			t.Diagnostics = append(t.Diagnostics, t.newDiagnostic(rule, line))
+			t.clearRecord(fsm, false)
+			return true, nil
+		}
 		if rule.NewState != "" {
 			return false, fmt.Errorf("Error: %s. Rule Line: %d. Input Line: %s.", rule.NewState, rule.LineNum, line)
 		} else {
@@ -215,10 +328,8 @@ func (t *ParserOutput) handleOperations(rule TextFSMRule, fsm TextFSM, line stri
 }
 
 func (t *ParserOutput) clearRecord(fsm TextFSM, all bool) {
-	for name, value := range fsm.Values {
-		value.clearValue(all)
-		// For some reason, modifying curval in valobj using processValue is not reflecting.
-		// Setting it back to fsm.Values works. Need to understand this further
-		fsm.Values[name] = value
+	t.clearLevel(fsm, "", all)
+	for _, table := range fsm.table_names {
+		t.clearTable(fsm, table, all)
 	}
 }
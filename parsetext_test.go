@@ -552,6 +552,52 @@ Start
 			},
 		},
 	},
+	{
+		name: "Test Nested with mixed case names",
+		template: `Value List foo ((?P<Name>\w+):\s+(?P<if_age2>\d+)\s+(?P<State>\w{2})\s*)
+
+Start
+  ^\s*${foo}
+`,
+		data: " Bob: 32 NC\n Alice: 27 NY\n",
+		dict: []map[string]interface{}{
+			{
+				"foo": []map[string]string{
+					map[string]string{"Name": "Bob", "if_age2": "32", "State": "NC"},
+					map[string]string{"Name": "Alice", "if_age2": "27", "State": "NY"},
+				},
+			},
+		},
+	},
+	{
+		name: "Test Nested group with the name of a Value in the same rule",
+		template: `Value foo ((?P<name>\w+):\s+(?P<age>\d+))
+Value name (\w+)
+Value age (\d+)
+
+Start
+  ^${name}\s+${foo}\s+${age} -> Record
+  ^${foo} -> Record
+`,
+		data: "Julia Bob: 32 45\nAlice: 27\n",
+		dict: []map[string]interface{}{
+			{
+				"foo":  map[string]string{"name": "Bob", "age": "32"},
+				"name": "Julia",
+				"age":  "45",
+			},
+			{
+				"foo":  map[string]string{"name": "Alice", "age": "27"},
+				"name": "",
+				"age":  "",
+			},
+		},
+	},
+	{
+		name:        "Test Nested group inside a nested group",
+		template:    "Value foo ((?P<name>(?P<first>\\w+)\\s\\w+))\n\nStart\n  ^${foo}\n",
+		compile_err: regexp.MustCompile(`Nested named group 'first' inside 'name' is not supported`),
+	},
 	{
 		name: "Simple state change, no actions",
 		template: `Value boo (one)
@@ -1251,3 +1297,85 @@ The operation is not supported on the specified slot or subslot.
 		},
 	},
 }
+
+var allocTemplate = `Value Filldown device (\S+)
+Value Required interface (\S+)
+Value mtu (\d+)
+Value List vlans (\d+)
+Value address ((?P<ip>[\d.]+)/(?P<mask>\d+))
+
+Start
+  ^Device: ${device}
+  ^Interface ${interface}
+  ^\s+mtu ${mtu}
+  ^\s+vlan ${vlans}
+  ^\s+address ${address}
+  ^$$ -> Record
+`
+
+func newAllocParser(t testing.TB, combined bool) (TextFSM, *ParserOutput) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString(allocTemplate); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	if combined {
+		fsm.EnableCombinedMatching()
+	}
+	out := &ParserOutput{}
+	out.Reset(fsm)
+	return fsm, out
+}
+
+// TestCheckLineAllocs guards the number of allocations done for each line of input.
+func TestCheckLineAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("Allocations are not counted right with the race detector")
+	}
+	testcases := []struct {
+		line   string
+		allocs float64
+	}{
+		// Lines that do not match any rule must not allocate.
+		{line: "  this line matches nothing", allocs: 0},
+		{line: "Interface", allocs: 0},
+		// Indexes of the match and the new value.
+		{line: "Interface eth0", allocs: 2},
+		// Indexes of the match, the map of nested groups and the new value.
+		{line: "  address 10.0.0.1/24", allocs: 4},
+	}
+	for _, combined := range []bool{false, true} {
+		fsm, out := newAllocParser(t, combined)
+		for _, tc := range testcases {
+			allocs := testing.AllocsPerRun(100, func() {
+				if err := out.checkLine(tc.line, fsm); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if allocs > tc.allocs {
+				t.Errorf("'%s' failed. Combined: %v. Expected at most %v allocations. Got %v", tc.line, combined, tc.allocs, allocs)
+			}
+		}
+	}
+}
+
+func benchmarkCheckLine(b *testing.B, lines []string, combined bool) {
+	fsm, out := newAllocParser(b, combined)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			if err := out.checkLine(line, fsm); err != nil {
+				b.Fatal(err)
+			}
+		}
+		out.Dict = out.Dict[:0]
+	}
+}
+
+var allocRecordLines = []string{"Device: sw1", "Interface eth0", "  mtu 1500", "  vlan 10", "  vlan 20", "  address 10.0.0.1/24", "  some other line", ""}
+
+func BenchmarkCheckLineNoMatch(b *testing.B) {
+	benchmarkCheckLine(b, []string{"  this line matches nothing"}, false)
+}
+func BenchmarkCheckLineRecord(b *testing.B)         { benchmarkCheckLine(b, allocRecordLines, false) }
+func BenchmarkCheckLineRecordCombined(b *testing.B) { benchmarkCheckLine(b, allocRecordLines, true) }
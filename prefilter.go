@@ -0,0 +1,196 @@
+package gotextfsm
+
+import (
+	"regexp/syntax"
+	"strings"
+)
+
+// extractLiterals finds the literals that must be present in a line for the regex to match.
+//     Args:
+//       regex: (string), The regular expression of a rule.
+//     Returns:
+//       prefix: Literal that a matching line must start with. Empty if the regex is not anchored by '^'
+//               or does not start with a literal.
+//       required: Longest literal that must appear somewhere in a matching line. Empty if none.
+//
+// Only the top level sequence of the regex is inspected (capture groups are looked into).
+// Anything that is not a plain case sensitive literal ends the current literal.
+// So the results are conservative. A line that has them may still not match, but a line that
+// does not have them can never match.
+func extractLiterals(regex string) (prefix string, required string) {
+	re, err := syntax.Parse(regex, syntax.Perl)
+	if err != nil {
+		return "", ""
+	}
+	items := flattenConcat(re)
+	var sb strings.Builder
+	in_prefix := false
+	for i, item := range items {
+		if item.Op == syntax.OpBeginText || item.Op == syntax.OpBeginLine {
+			if i == 0 {
+				in_prefix = true
+			}
+			continue
+		}
+		if item.Op == syntax.OpEmptyMatch {
+			continue
+		}
+		if item.Op == syntax.OpLiteral && item.Flags&syntax.FoldCase == 0 {
+			sb.WriteString(string(item.Rune))
+			continue
+		}
+		if in_prefix {
+			prefix = sb.String()
+			in_prefix = false
+		}
+		if sb.Len() > len(required) {
+			required = sb.String()
+		}
+		sb.Reset()
+	}
+	if in_prefix {
+		prefix = sb.String()
+	}
+	if sb.Len() > len(required) {
+		required = sb.String()
+	}
+	return prefix, required
+}
+
+// flattenConcat returns the sequence of sub expressions of the regex, looking into concatenations and capture groups.
+func flattenConcat(re *syntax.Regexp) []*syntax.Regexp {
+	switch re.Op {
+	case syntax.OpConcat:
+		items := make([]*syntax.Regexp, 0)
+		for _, sub := range re.Sub {
+			items = append(items, flattenConcat(sub)...)
+		}
+		return items
+	case syntax.OpCapture:
+		return flattenConcat(re.Sub[0])
+	}
+	return []*syntax.Regexp{re}
+}
+
+// mayMatch does a quick check of the line against the literals of the rule.
+// Returns false if the rule can not match the line. true if it may.
+func (r *TextFSMRule) mayMatch(line string) bool {
+	if r.prefix != "" && !strings.HasPrefix(line, r.prefix) {
+		return false
+	}
+	if r.required != "" && !strings.Contains(line, r.required) {
+		return false
+	}
+	return true
+}
+
+// EnableCombinedMatching builds a single automaton for each state that combines the literals of all its rules.
+// The automaton (Aho-Corasick) finds, in one pass over a line, every rule whose required literal is present in the line.
+// Only those rules (and the rules that have no literal) are then tried in order with their regular expressions.
+//
+// This is optional. It helps templates with many rules in a state. For small templates the literal prefilter,
+// which is always enabled, is enough.
+// Must be called after the template is parsed.
+func (t *TextFSM) EnableCombinedMatching() {
+	for name, state := range t.States {
+		literals := make([]string, len(state.rules))
+		for i, rule := range state.rules {
+			literals[i] = rule.required
+		}
+		state.matcher = newLiteralMatcher(literals)
+		t.States[name] = state
+	}
+}
+
+// literalMatcher is an Aho-Corasick automaton that finds which of a set of literals are present in a string.
+type literalMatcher struct {
+	next   []map[byte]int
+	fail   []int
+	output [][]int
+	// Indexes of the empty literals. These are always present.
+	always []int
+	count  int
+}
+
+// newLiteralMatcher builds the automaton for the literals. The index of a literal in the slice identifies it.
+func newLiteralMatcher(literals []string) *literalMatcher {
+	m := &literalMatcher{
+		next:   []map[byte]int{{}},
+		fail:   []int{0},
+		output: [][]int{nil},
+		always: make([]int, 0),
+		count:  len(literals),
+	}
+	for idx, literal := range literals {
+		if literal == "" {
+			m.always = append(m.always, idx)
+			continue
+		}
+		node := 0
+		for i := 0; i < len(literal); i++ {
+			child, exists := m.next[node][literal[i]]
+			if !exists {
+				child = len(m.next)
+				m.next = append(m.next, map[byte]int{})
+				m.fail = append(m.fail, 0)
+				m.output = append(m.output, nil)
+				m.next[node][literal[i]] = child
+			}
+			node = child
+		}
+		m.output[node] = append(m.output[node], idx)
+	}
+	// Breadth first traversal to set the failure links.
+	queue := make([]int, 0)
+	for _, child := range m.next[0] {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for c, child := range m.next[node] {
+			f := m.fail[node]
+			for {
+				if target, exists := m.next[f][c]; exists && target != child {
+					m.fail[child] = target
+					break
+				}
+				if f == 0 {
+					break
+				}
+				f = m.fail[f]
+			}
+			m.output[child] = append(m.output[child], m.output[m.fail[child]]...)
+			queue = append(queue, child)
+		}
+	}
+	return m
+}
+
+// find marks in 'found' the literals that are present in the line.
+// found must have one element per literal.
+func (m *literalMatcher) find(line string, found []bool) {
+	for i := range found {
+		found[i] = false
+	}
+	for _, idx := range m.always {
+		found[idx] = true
+	}
+	node := 0
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		for {
+			if child, exists := m.next[node][c]; exists {
+				node = child
+				break
+			}
+			if node == 0 {
+				break
+			}
+			node = m.fail[node]
+		}
+		for _, idx := range m.output[node] {
+			found[idx] = true
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package gotextfsm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type literalTestCase struct {
+	regex    string
+	prefix   string
+	required string
+}
+
+func TestExtractLiterals(t *testing.T) {
+	for _, tc := range literalTestCases {
+		prefix, required := extractLiterals(tc.regex)
+		if prefix != tc.prefix {
+			t.Errorf("'%s' failed. Prefix dont match ('%s', '%s')", tc.regex, tc.prefix, prefix)
+		}
+		if required != tc.required {
+			t.Errorf("'%s' failed. Required literal dont match ('%s', '%s')", tc.regex, tc.required, required)
+		}
+	}
+	t.Logf("Executed %d test cases", len(literalTestCases))
+}
+
+var literalTestCases = []literalTestCase{
+	{regex: `^Interface (?P<name>\S+)`, prefix: "Interface ", required: "Interface "},
+	{regex: `^\s+Description: (?P<desc>.*)`, prefix: "", required: "Description: "},
+	{regex: `Interface (?P<name>\S+)`, prefix: "", required: "Interface "},
+	{regex: `^(?P<name>Vlan)\d+ is up`, prefix: "Vlan", required: " is up"},
+	{regex: `^(?i)interface`, prefix: "", required: ""},
+	{regex: `^(ab|cd)ef`, prefix: "", required: "ef"},
+	{regex: `^abc?`, prefix: "ab", required: "ab"},
+	{regex: `^$`, prefix: "", required: ""},
+	{regex: `.*`, prefix: "", required: ""},
+	{regex: `^\s*$`, prefix: "", required: ""},
+	{regex: `^Total: (?P<total>\d+) entries$`, prefix: "Total: ", required: " entries"},
+	{regex: `(`, prefix: "", required: ""},
+}
+
+func TestCombinedMatching(t *testing.T) {
+	count := 0
+	for _, tc := range parseTestCases {
+		if tc.compile_err != nil || tc.reset != nil || tc.data1 != "" {
+			continue
+		}
+		eof := true
+		if tc.eof != nil {
+			eof = *tc.eof
+		}
+		fsm := TextFSM{}
+		if err := fsm.ParseString(tc.template); err != nil {
+			t.Errorf("'%s' failed. TextFSM should be valid. But got error '%s'", tc.name, err.Error())
+			continue
+		}
+		expected := ParserOutput{}
+		experr := expected.ParseTextString(tc.data, fsm, eof)
+		fsm.EnableCombinedMatching()
+		count++
+		got := ParserOutput{}
+		goterr := got.ParseTextString(tc.data, fsm, eof)
+		if (experr == nil) != (goterr == nil) {
+			t.Errorf("'%s' failed. Errors dont match ('%v', '%v')", tc.name, experr, goterr)
+			continue
+		}
+		if len(expected.Dict) != len(got.Dict) {
+			t.Errorf("'%s' failed. Expected %d records. Got %d records", tc.name, len(expected.Dict), len(got.Dict))
+			continue
+		}
+		for idx, exprec := range expected.Dict {
+			if err := comparedicts(tc, exprec, got.Dict[idx], idx); err != "" {
+				t.Error(err)
+				break
+			}
+		}
+	}
+	t.Logf("Executed %d test cases", count)
+}
+
+// largeTemplate returns a template with many rules, each starting with a different literal,
+// and an input that exercises all of them.
+func largeTemplate(rules int) (string, string) {
+	var template, input strings.Builder
+	for i := 0; i < rules; i++ {
+		template.WriteString(fmt.Sprintf("Value field%d (\\S+)\n", i))
+	}
+	template.WriteString("\nStart\n")
+	for i := 0; i < rules; i++ {
+		template.WriteString(fmt.Sprintf("  ^\\s*Field%d\\s+is\\s+${field%d}\n", i, i))
+	}
+	template.WriteString("  ^Record -> Record\n")
+	for r := 0; r < 20; r++ {
+		for i := rules - 1; i >= 0; i-- {
+			input.WriteString(fmt.Sprintf("  Field%d is value%d\n", i, r))
+			input.WriteString("  an unrelated line that matches nothing\n")
+		}
+		input.WriteString("Record\n")
+	}
+	return template.String(), input.String()
+}
+
+// disablePrefilter clears the literals of the rules. So that every rule is tried with its regex,
+// the way lines are parsed without the prefilter.
+func disablePrefilter(fsm TextFSM) {
+	for _, state := range fsm.States {
+		for i := range state.rules {
+			state.rules[i].prefix = ""
+			state.rules[i].required = ""
+		}
+	}
+}
+
+func benchmarkLargeTemplate(b *testing.B, rules int, prefilter bool, combined bool) {
+	template, input := largeTemplate(rules)
+	fsm := TextFSM{}
+	if err := fsm.ParseString(template); err != nil {
+		b.Fatal(err)
+	}
+	if !prefilter {
+		disablePrefilter(fsm)
+	}
+	if combined {
+		fsm.EnableCombinedMatching()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		out := ParserOutput{}
+		if err := out.ParseTextString(input, fsm, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLargeTemplateNaive(b *testing.B)     { benchmarkLargeTemplate(b, 200, false, false) }
+func BenchmarkLargeTemplatePrefilter(b *testing.B) { benchmarkLargeTemplate(b, 200, true, false) }
+func BenchmarkLargeTemplateCombined(b *testing.B)  { benchmarkLargeTemplate(b, 200, true, true) }
+
+func TestLiteralMatcher(t *testing.T) {
+	literals := []string{"he", "she", "his", "hers", "", "Field1", "Field12"}
+	matcher := newLiteralMatcher(literals)
+	testcases := map[string][]bool{
+		"ushers":      {true, true, false, true, true, false, false},
+		"this":        {false, false, true, false, true, false, false},
+		"Field12 is":  {false, false, false, false, true, true, true},
+		"Field2 is":   {false, false, false, false, true, false, false},
+		"":            {false, false, false, false, true, false, false},
+		"hishe Field": {true, true, true, false, true, false, false},
+	}
+	for line, expected := range testcases {
+		found := make([]bool, len(literals))
+		matcher.find(line, found)
+		for i := range literals {
+			if found[i] != expected[i] {
+				t.Errorf("'%s' failed. Literal '%s' expected %v. Got %v", line, literals[i], expected[i], found[i])
+			}
+		}
+	}
+}
@@ -1,56 +1,81 @@
 package gotextfsm
 
 import (
-	"regexp"
-	"sort"
+	"fmt"
 	"strings"
-	"text/template"
 )
 
-// ExecutePythonTemplate converts a python template into a golang template and executes
-// against the given variable map.
-// This is a quick hack and not does not support all comprehensive list of Python Template package.
-// It does the following:
-// 	* replace all ${varname} to {{.varname}}
-// 	* replace all $varname to {{.varname}} (By sorting the varnames with the longest name first)
-// 	* replace all $$ with $
-//  * escape all literal {{ and }} with {{"{{"}} and {{"}}"}}
-// Assumes it is a valid python Template. No validations done to validate the python template syntax.
-// Assumes all the variables are proper variable identifiers
-// Then executes the resulting golang template on the map passed.
-func ExecutePythonTemplate(pytemplate string, vars_map map[string]interface{}) (string, error) {
-	t := pytemplate
-	// Replace ${xxxx} with {{.xxxx}}
-	r1 := regexp.MustCompile(`\$\{([^$\{\}]+)\}`)
-	t = r1.ReplaceAllString(t, "__DOUBLE_OPENBR__ .$1 __DOUBLE_CLOSEBR__")
-	if vars_map != nil {
-		keys := make([]string, 0, len(vars_map))
-		for k := range vars_map {
-			keys = append(keys, k)
+// ExecutePythonTemplate substitutes the variables in a python template (string.Template) with the values from the
+// variable map. It behaves same as Template.substitute() of Python:
+// 	* $$ is an escape and is replaced with a single $
+// 	* $identifier is replaced by the value of 'identifier'. The identifier is the longest sequence of
+// 	  ASCII letters, digits and underscores, that starts with a letter or underscore.
+// 	* ${identifier} is same as $identifier. Used when the identifier is followed by valid identifier characters.
+// Any other use of $ is an invalid placeholder. ex: '$' at the end of the template, '${never ending', '${top.bottom}'
+// Values are converted to string with fmt.Sprint.
+// Returns error (with line and column of the $) if there is an invalid placeholder or if an identifier is not found in the map.
+func ExecutePythonTemplate(pytemplate string, vars_map map[string]interface{}) (output string, err error) {
+	defer recoverInternalError("ExecutePythonTemplate", &err)
+	var sb strings.Builder
+	sb.Grow(len(pytemplate))
+	i := 0
+	for {
+		idx := strings.IndexByte(pytemplate[i:], '$')
+		if idx < 0 {
+			sb.WriteString(pytemplate[i:])
+			break
 		}
-		// Sort by longest key first.
-		// This is done so that $var1234 is replaced first before replacing $var12 for example.
-		sort.SliceStable(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
-		for _, key := range keys {
-			t = regexp.MustCompile(`\$(`+key+`)`).ReplaceAllString(t, "__DOUBLE_OPENBR__ .$1 __DOUBLE_CLOSEBR__")
+		sb.WriteString(pytemplate[i : i+idx])
+		i += idx
+		rest := pytemplate[i+1:]
+		var name string
+		var length int
+		switch {
+		case strings.HasPrefix(rest, "$"):
+			sb.WriteString("$")
+			i += 2
+			continue
+		case strings.HasPrefix(rest, "{"):
+			name = pyIdentifier(rest[1:])
+			if name == "" || !strings.HasPrefix(rest[1+len(name):], "}") {
+				return "", pyPlaceholderError("Invalid placeholder in string", pytemplate, i)
+			}
+			length = len(name) + 3
+		default:
+			name = pyIdentifier(rest)
+			if name == "" {
+				return "", pyPlaceholderError("Invalid placeholder in string", pytemplate, i)
+			}
+			length = len(name) + 1
 		}
+		val, exists := vars_map[name]
+		if !exists {
+			return "", pyPlaceholderError(fmt.Sprintf("Unknown variable '%s'", name), pytemplate, i)
+		}
+		sb.WriteString(fmt.Sprint(val))
+		i += length
 	}
-	// Replace $$ with $
-	t = strings.ReplaceAll(t, "$$", "$")
-	// Escape { and } with {{"{"}} and {{"}"}}
-	// Generally speaking, golang template has special meeaning for {{ and }}. Hence, we should escape only {{ and }}
-	//
-	// But. Looks like golang template barks at something like \{{{.INBOUND_SETTINGS_IN_USE}}
-	// Hence, we escape every single { and } instead of only {{ and }}
-	t = strings.ReplaceAll(t, "{", `__DOUBLE_OPENBR__"{"__DOUBLE_CLOSEBR__`)
-	t = strings.ReplaceAll(t, "}", `__DOUBLE_OPENBR__"}"__DOUBLE_CLOSEBR__`)
-	t = strings.ReplaceAll(t, "__DOUBLE_OPENBR__", `{{`)
-	t = strings.ReplaceAll(t, "__DOUBLE_CLOSEBR__", `}}`)
-	var sb strings.Builder
-	gotemplate, err := template.New("test").Parse(t)
-	if err != nil {
-		return "", err
-	}
-	gotemplate.Execute(&sb, vars_map)
 	return sb.String(), nil
 }
+
+// pyIdentifier returns the identifier at the start of str. Empty string if str does not start with an identifier.
+// Identifiers are same as the default idpattern of string.Template: (?i)[_a-z][_a-z0-9]*
+func pyIdentifier(str string) string {
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return str[:i]
+	}
+	return str
+}
+
+// pyPlaceholderError returns the error for the placeholder at the byte offset pos of the template.
+// Line and column (in characters) start with 1.
+func pyPlaceholderError(msg string, pytemplate string, pos int) error {
+	line_start := strings.LastIndexByte(pytemplate[:pos], '\n') + 1
+	line := strings.Count(pytemplate[:pos], "\n") + 1
+	col := len([]rune(pytemplate[line_start:pos])) + 1
+	return fmt.Errorf("%s: line %d, col %d", msg, line, col)
+}
@@ -8,15 +8,17 @@ type pyTemplateTestcase struct {
 	input  string
 	vars   map[string]interface{}
 	output string
-	err    bool
+	err    string
 }
 
 func TestPyTemplate(t *testing.T) {
 	for _, tc := range pyTemplateTestcases {
 		output, err := ExecutePythonTemplate(tc.input, tc.vars)
-		if tc.err {
+		if tc.err != "" {
 			if err == nil {
 				t.Errorf("'%s' failed. Expected error, but none found", tc.input)
+			} else if err.Error() != tc.err {
+				t.Errorf("'%s' failed. Errors dont match ('%s', '%s')", tc.input, err, tc.err)
 			}
 			continue
 		}
@@ -43,24 +45,64 @@ var pyTemplateTestcases = []pyTemplateTestcase{
 		output: `Hello Siri`,
 	},
 	{
-		input:  `Hello ${intVal} Hi ${floatVal} ${never ending`,
-		vars:   map[string]interface{}{"intVal": 10, "floatVal": 5.2, "never ending": "Dummy"},
-		output: `Hello 10 Hi 5.2 ${never ending`,
+		input:  `Hello ${intVal} Hi ${floatVal}`,
+		vars:   map[string]interface{}{"intVal": 10, "floatVal": 5.2},
+		output: `Hello 10 Hi 5.2`,
 	},
 	{
-		input:  `Hello ${top.bottom} Hi ${floatVal}`,
-		vars:   map[string]interface{}{"top": map[string]interface{}{"bottom": "Structure"}, "floatVal": 5.2},
-		output: `Hello Structure Hi 5.2`,
+		input: `Hello ${intVal} Hi ${floatVal} ${never ending`,
+		vars:  map[string]interface{}{"intVal": 10, "floatVal": 5.2, "never ending": "Dummy"},
+		err:   "Invalid placeholder in string: line 1, col 32",
 	},
 	{
-		input:  `Hello ${top.bottom} Hi $no_variable`,
-		vars:   map[string]interface{}{"top": map[string]interface{}{"bottom": "Structure"}},
-		output: `Hello Structure Hi $no_variable`,
+		input: `Hello ${top.bottom} Hi ${floatVal}`,
+		vars:  map[string]interface{}{"top": map[string]interface{}{"bottom": "Structure"}, "floatVal": 5.2},
+		err:   "Invalid placeholder in string: line 1, col 7",
 	},
 	{
-		input:  `Escape $$ with $ $$$temp`,
+		input: `Hello $world Hi $no_variable`,
+		vars:  map[string]interface{}{"world": "Siri"},
+		err:   "Unknown variable 'no_variable': line 1, col 17",
+	},
+	{
+		input: "Hello $world\nHi ${no_variable}",
+		vars:  map[string]interface{}{"world": "Siri"},
+		err:   "Unknown variable 'no_variable': line 2, col 4",
+	},
+	{
+		input: "Hellö\n  Wörld $",
+		vars:  map[string]interface{}{"world": "Siri"},
+		err:   "Invalid placeholder in string: line 2, col 9",
+	},
+	{
+		input: `Hello ${ world }`,
+		vars:  map[string]interface{}{"world": "Siri"},
+		err:   "Invalid placeholder in string: line 1, col 7",
+	},
+	{
+		input: `Escape $$ with $ $$$temp`,
+		vars:  map[string]interface{}{"world": "Siri", "world123": "Bigger", "temp": "Dummy"},
+		err:   "Invalid placeholder in string: line 1, col 16",
+	},
+	{
+		input:  `Escape $$ with $$ $$$temp and $$$$`,
 		vars:   map[string]interface{}{"world": "Siri", "world123": "Bigger", "temp": "Dummy"},
-		output: `Escape $ with $ $Dummy`,
+		output: `Escape $ with $ $Dummy and $$`,
+	},
+	{
+		input:  `Hello $World_2x and ${World_2}x $_`,
+		vars:   map[string]interface{}{"World_2x": "Siri", "World_2": "Bigger", "_": "Under"},
+		output: `Hello Siri and Biggerx Under`,
+	},
+	{
+		input:  `Hello $world.$world-$world`,
+		vars:   map[string]interface{}{"world": "Siri"},
+		output: `Hello Siri.Siri-Siri`,
+	},
+	{
+		input:  `Values are not templates {{.world}} $world`,
+		vars:   map[string]interface{}{"world": "{{.world}}"},
+		output: `Values are not templates {{.world}} {{.world}}`,
 	},
 	{
 		input:  `Hello $world123 Hi ${world}`,
@@ -3,20 +3,36 @@ package gotextfsm
 import (
 	"fmt"
 	"regexp"
+	"regexp/syntax"
 	"strings"
 )
 
 type TextFSMRule struct {
-	Regex    string
-	Match    string
-	LineOp   string
-	RecordOp string
-	NewState string
-	LineNum  int
+	Regex        string
+	Match        string
+	LineOp       string
+	RecordOp     string
+	RecordTarget string // Name of the level (or table) the 'Record' operator applies to. Empty for top level records.
+	NewState     string
+	Call         bool // NewState is called. 'Return' in a called state returns to the state that called it.
+	LineNum      int
+	source       string
+	values       []string
+	regex        *regexp.Regexp
+	prefix       string
+	required     string
+	groups       map[string]int
+	nested       map[string]map[string]int
+	level        string            // Path of the level of RecordTarget. ex. 'Addresses.Secondary'
+	table        string            // RecordTarget, if it is a table.
+	levels       map[string]string // Paths of the levels of the template by their names. Set before Parse.
+	tables       []string          // Tables of the template. Set before Parse.
+	origin       string            // File and line the rule was read from. See ParseFS.
 }
 
 var LINE_OPERATORS = []string{"Continue", "Next", "Error"}
 var RECORD_OPERATORS = []string{"Clear", "Clearall", "Record", "NoRecord"}
+var STATE_OPERATORS = []string{"Call", "Return"}
 
 func (t *TextFSMRule) String() string {
 	var sb strings.Builder
@@ -26,23 +42,110 @@ func (t *TextFSMRule) String() string {
 		if t.RecordOp != "" {
 			sb.WriteString("." + t.RecordOp)
 		}
+		if t.RecordTarget != "" {
+			sb.WriteString(" " + t.RecordTarget)
+		}
+		if t.Call {
+			sb.WriteString(" Call")
+		}
 		if t.NewState != "" {
 			sb.WriteString(" " + t.NewState)
 		}
 	} else {
 		if t.RecordOp != "" {
 			sb.WriteString(" -> " + t.RecordOp)
+			if t.RecordTarget != "" {
+				sb.WriteString(" " + t.RecordTarget)
+			}
+			if t.Call {
+				sb.WriteString(" Call")
+			}
 			if t.NewState != "" {
 				sb.WriteString(" " + t.NewState)
 			}
+		} else if t.Call {
+			sb.WriteString(" -> Call " + t.NewState)
 		} else if t.NewState != "" {
 			sb.WriteString(" -> " + t.NewState)
 		}
 	}
 	return sb.String()
 }
-func (r *TextFSMRule) Parse(line string, lineNum int, var_map map[string]interface{}) error {
+
+// Source returns the line of the template from which the rule was parsed.
+func (r *TextFSMRule) Source() string {
+	return r.source
+}
+
+// Origin returns the file and the line of the template the rule was read from. ex. 'base.textfsm:12'
+// Empty if the template was not read by ParseFS.
+func (r *TextFSMRule) Origin() string {
+	return r.origin
+}
+
+// Values returns the names of the Values referenced by the rule, in the order of their first appearance.
+// The returned slice is a copy and can be modified by the caller.
+func (r *TextFSMRule) Values() []string {
+	return append([]string{}, r.values...)
+}
+
+// submatch returns the text matched by the group of the Value, given the indexes of a match of the rule.
+// Returns empty string if the group did not participate in the match.
+func (r *TextFSMRule) submatch(line string, loc []int, name string) string {
+	idx, exists := r.groups[name]
+	if !exists {
+		return ""
+	}
+	return groupText(line, loc, idx)
+}
+
+// nestedSubmatch returns the text matched by the nested group 'name' of the Value, given the indexes of a match of the rule.
+// Returns empty string if the group did not participate in the match.
+func (r *TextFSMRule) nestedSubmatch(line string, loc []int, value string, name string) string {
+	idx, exists := r.nested[value][name]
+	if !exists {
+		return ""
+	}
+	return groupText(line, loc, idx)
+}
+
+func groupText(line string, loc []int, idx int) string {
+	if loc[2*idx] < 0 {
+		return ""
+	}
+	return line[loc[2*idx]:loc[2*idx+1]]
+}
+
+// indexGroups finds the groups of the Values (names in var_map) in the parsed regex of the rule.
+// Named groups nested inside the group of a Value belong to that Value, even when they have the name of another Value.
+// So that a nested group and a Value with the same name do not clash.
+// When a Value is used more than once, the last group wins.
+//     Args:
+//       re: Parsed regex (or a part of it)
+//       value: Name of the Value, the group of which encloses re. Empty if there is none.
+func (r *TextFSMRule) indexGroups(re *syntax.Regexp, var_map map[string]interface{}, value string) {
+	if re.Op == syntax.OpCapture && re.Name != "" {
+		if value != "" {
+			r.nested[value][re.Name] = re.Cap
+		} else if _, exists := var_map[re.Name]; exists {
+			value = re.Name
+			r.groups[value] = re.Cap
+			r.nested[value] = make(map[string]int)
+			if FindIndex(r.values, value) < 0 {
+				r.values = append(r.values, value)
+			}
+		}
+	}
+	for _, sub := range re.Sub {
+		r.indexGroups(sub, var_map, value)
+	}
+}
+
+func (r *TextFSMRule) Parse(line string, lineNum int, var_map map[string]interface{}) (err error) {
+	defer recoverInternalError("ParseRule", &err)
 	r.LineNum = lineNum
+	r.source = line
+	r.values = make([]string, 0)
 	// Implicit default is '(regexp) -> Next.NoRecord'
 	MATCH_ACTION := regexp.MustCompile(`(?P<match>.*)(\s->(?P<action>.*))`)
 	// Line operators.
@@ -53,12 +156,16 @@ func (r *TextFSMRule) Parse(line string, lineNum int, var_map map[string]interfa
 	OPER_RECORD_RE := regexp.MustCompile(fmt.Sprintf("(%s(%s%s)?)", OPER_RE, `\.`, RECORD_RE))
 	// New State or 'Error' string.
 	NEWSTATE_RE := regexp.MustCompile(`(?P<new_state>\w+|\".*\")`)
-	// Compound operator (line and record) with optional new state.
-	ACTION_RE := regexp.MustCompile(fmt.Sprintf("^%s%s(%s%s)?$", `\s+`, OPER_RECORD_RE, `\s+`, NEWSTATE_RE))
-	// Record operator with optional new state.
-	ACTION2_RE := regexp.MustCompile(fmt.Sprintf("^%s%s(%s%s)?$", `\s+`, RECORD_RE, `\s+`, NEWSTATE_RE))
-	// Default operators with optional new state.
-	ACTION3_RE := regexp.MustCompile(fmt.Sprintf("^(%s%s)?$", `\s+`, NEWSTATE_RE))
+	// Level (or table) of the record. Followed by the new state.
+	TARGET_RE := regexp.MustCompile(`(?P<rec_target>\w+)`)
+	// New state is called. ex. 'Call AddressFamily'
+	CALL_RE := regexp.MustCompile(`(?P<call>Call)`)
+	// Compound operator (line and record) with optional level (or table) and new state (optionally called).
+	ACTION_RE := regexp.MustCompile(fmt.Sprintf("^%s%s((%s%s)??(%s%s)?%s%s)?$", `\s+`, OPER_RECORD_RE, `\s+`, TARGET_RE, `\s+`, CALL_RE, `\s+`, NEWSTATE_RE))
+	// Record operator with optional level (or table) and new state (optionally called).
+	ACTION2_RE := regexp.MustCompile(fmt.Sprintf("^%s%s((%s%s)??(%s%s)?%s%s)?$", `\s+`, RECORD_RE, `\s+`, TARGET_RE, `\s+`, CALL_RE, `\s+`, NEWSTATE_RE))
+	// Default operators with optional new state (optionally called).
+	ACTION3_RE := regexp.MustCompile(fmt.Sprintf("^((%s%s)?%s%s)?$", `\s+`, CALL_RE, `\s+`, NEWSTATE_RE))
 	line = strings.TrimSpace(line)
 	if line == "" {
 		return fmt.Errorf("Null data in FSMRule. Line: %d", r.LineNum)
@@ -73,13 +180,21 @@ func (r *TextFSMRule) Parse(line string, lineNum int, var_map map[string]interfa
 	if var_map != nil {
 		regex, err := ExecutePythonTemplate(r.Match, var_map)
 		if err != nil {
-			return err
+			return fmt.Errorf("Line %d: Invalid variable substitution '%s'. Error: '%s'", r.LineNum, r.Match, err.Error())
 		}
 		r.Regex = regex
 	}
-	if _, err := regexp.Compile(r.Regex); err != nil {
+	regex, err := regexp.Compile(r.Regex)
+	if err != nil {
 		return fmt.Errorf("Line %d: Invalid regular expression '%s'. Error: '%s'", r.LineNum, r.Regex, err.Error())
 	}
+	r.regex = regex
+	r.prefix, r.required = extractLiterals(r.Regex)
+	r.groups = make(map[string]int)
+	r.nested = make(map[string]map[string]int)
+	if parsed, err := syntax.Parse(r.Regex, syntax.Perl); err == nil {
+		r.indexGroups(parsed, var_map, "")
+	}
 	if _, err := regexp.Compile(r.Match); err != nil {
 		return fmt.Errorf("Line %d: Invalid regular expression '%s'. Error: '%s'", r.LineNum, r.Match, err.Error())
 	}
@@ -103,6 +218,38 @@ func (r *TextFSMRule) Parse(line string, lineNum int, var_map map[string]interfa
 	if new_state, exists := m["new_state"]; exists {
 		r.NewState = new_state
 	}
+	if call, exists := m["call"]; exists && call != "" {
+		if r.LineOp == "Error" || r.LineOp == "Continue" {
+			return fmt.Errorf("Action '%s' can not call a state. Line: %d.", r.LineOp, r.LineNum)
+		}
+		if r.NewState == "Return" {
+			return fmt.Errorf("'Return' can not be called. Line: %d.", r.LineNum)
+		}
+		r.Call = true
+	}
+	if rec_target, exists := m["rec_target"]; exists && rec_target != "" {
+		if r.RecordOp != "Record" {
+			return fmt.Errorf("Level or table '%s' given without 'Record' operator. Line: %d.", rec_target, r.LineNum)
+		}
+		r.RecordTarget = rec_target
+	}
+	// A single word after 'Record' is the level (or table), if there is one by that name. Else, it is the new state.
+	if r.RecordOp == "Record" && r.RecordTarget == "" && !r.Call {
+		_, is_level := r.levels[r.NewState]
+		if is_level || FindIndex(r.tables, r.NewState) >= 0 {
+			r.RecordTarget = r.NewState
+			r.NewState = ""
+		}
+	}
+	if r.RecordTarget != "" {
+		if FindIndex(r.tables, r.RecordTarget) >= 0 {
+			r.table = r.RecordTarget
+		} else if level, exists := r.levels[r.RecordTarget]; exists {
+			r.level = level
+		} else {
+			return fmt.Errorf("Level or table '%s' not found. Line: %d.", r.RecordTarget, r.LineNum)
+		}
+	}
 	// Only 'Next' (or implicit 'Next') line operator can have a new_state.
 	// But we allow error to have one as a warning message so we are left
 	// checking that Continue does not.
@@ -0,0 +1,262 @@
+package gotextfsm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// parserSnapshot is the JSON representation of the state of ParserOutput.
+type parserSnapshot struct {
+	State       string                                  `json:"state"`
+	Stack       []string                                `json:"stack,omitempty"`
+	LineNum     int                                     `json:"line_num"`
+	Partial     string                                  `json:"partial,omitempty"`
+	Closed      bool                                    `json:"closed,omitempty"`
+	Values      map[string]json.RawMessage              `json:"values"`
+	Filldown    map[string]json.RawMessage              `json:"filldown"`
+	Records     []map[string]json.RawMessage            `json:"records"`
+	Children    map[string][]map[string]json.RawMessage `json:"children,omitempty"`
+	Tables      map[string][]map[string]json.RawMessage `json:"tables,omitempty"`
+	Diagnostics []Diagnostic                            `json:"diagnostics,omitempty"`
+	Unmatched   []UnmatchedLine                         `json:"unmatched,omitempty"`
+}
+
+// Snapshot serializes the current state of parsing to JSON.
+// The state consists of the current state name (and the states to return to), the line number, the values of the record being built,
+// the Filldown values, the records emitted (to Dict and Tables, and Diagnostics, Unmatched lines collected) so far and the incomplete line passed to ParseChunk (if any).
+//
+// The state can be restored later with Restore (ex. after a restart) and parsing continued.
+//     Returns:
+//       JSON document and error if there is any error while serializing.
+func (t *ParserOutput) Snapshot() (data []byte, err error) {
+	defer t.recoverInternalError("Snapshot", &err)
+	snapshot := parserSnapshot{
+		State:       t.cur_state_name,
+		Stack:       t.stack,
+		LineNum:     t.line_num,
+		Partial:     string(t.partial),
+		Closed:      t.closed,
+		Values:      make(map[string]json.RawMessage),
+		Filldown:    make(map[string]json.RawMessage),
+		Diagnostics: t.Diagnostics,
+		Unmatched:   t.Unmatched,
+	}
+	if snapshot.State == "" {
+		snapshot.State = "Start"
+	}
+	for name, value := range t.values {
+		curval, err := json.Marshal(value.curval)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Values[name] = curval
+		filldown, err := json.Marshal(value.filldown_value)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Filldown[name] = filldown
+	}
+	if snapshot.Records, err = encodeRecords(t.Dict); err != nil {
+		return nil, err
+	}
+	for table, records := range t.Tables {
+		if snapshot.Tables == nil {
+			snapshot.Tables = make(map[string][]map[string]json.RawMessage)
+		}
+		if snapshot.Tables[table], err = encodeRecords(records); err != nil {
+			return nil, err
+		}
+	}
+	for level, records := range t.children {
+		if snapshot.Children == nil {
+			snapshot.Children = make(map[string][]map[string]json.RawMessage)
+		}
+		if snapshot.Children[level], err = encodeRecords(records); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(snapshot)
+}
+
+// encodeRecords converts each value of the records to JSON.
+func encodeRecords(records []map[string]interface{}) ([]map[string]json.RawMessage, error) {
+	output := make([]map[string]json.RawMessage, 0, len(records))
+	for _, record := range records {
+		rec := make(map[string]json.RawMessage)
+		for name, val := range record {
+			data, err := json.Marshal(val)
+			if err != nil {
+				return nil, err
+			}
+			rec[name] = data
+		}
+		output = append(output, rec)
+	}
+	return output, nil
+}
+
+// Restore replaces the state of ParserOutput with the one serialized by Snapshot.
+// fsm must be the same template that was used when the snapshot was taken.
+//     Args:
+//       data: ([]byte), JSON document returned by Snapshot.
+//       fsm: (TextFSM), TextFSM object as a result of parsing the text fsm template
+//     Returns:
+//       error if the snapshot is invalid or does not fit the template. ParserOutput is unchanged in that case.
+func (t *ParserOutput) Restore(data []byte, fsm TextFSM) (err error) {
+	defer t.recoverInternalError("Restore", &err)
+	snapshot := parserSnapshot{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("Invalid snapshot. Error: %s", err.Error())
+	}
+	if _, exists := fsm.States[snapshot.State]; !exists && snapshot.State != "End" && snapshot.State != "EOF" {
+		return fmt.Errorf("Invalid snapshot. State '%s' not found in template", snapshot.State)
+	}
+	for _, state := range snapshot.Stack {
+		if _, exists := fsm.States[state]; !exists {
+			return fmt.Errorf("Invalid snapshot. State '%s' not found in template", state)
+		}
+	}
+	values := make(map[string]TextFSMValue)
+	for name, value := range fsm.Values {
+		value.clearValue(true)
+		values[name] = value
+	}
+	for name, raw := range snapshot.Values {
+		value, exists := values[name]
+		if !exists {
+			return fmt.Errorf("Invalid snapshot. Value '%s' not found in template", name)
+		}
+		curval, err := value.decodeValue(raw)
+		if err != nil {
+			return err
+		}
+		value.curval = curval
+		values[name] = value
+	}
+	for name, raw := range snapshot.Filldown {
+		value, exists := values[name]
+		if !exists {
+			return fmt.Errorf("Invalid snapshot. Value '%s' not found in template", name)
+		}
+		filldown, err := value.decodeValue(raw)
+		if err != nil {
+			return err
+		}
+		value.filldown_value = filldown
+		values[name] = value
+	}
+	dict, err := decodeRecords(fsm, snapshot.Records)
+	if err != nil {
+		return err
+	}
+	var children map[string][]map[string]interface{}
+	for level, raws := range snapshot.Children {
+		if FindIndex(fsm.level_paths, level) < 0 {
+			return fmt.Errorf("Invalid snapshot. Level '%s' not found in template", level)
+		}
+		records, err := decodeRecords(fsm, raws)
+		if err != nil {
+			return err
+		}
+		if children == nil {
+			children = make(map[string][]map[string]interface{})
+		}
+		children[level] = records
+	}
+	var tables map[string][]map[string]interface{}
+	for table, raws := range snapshot.Tables {
+		if FindIndex(fsm.table_names, table) < 0 {
+			return fmt.Errorf("Invalid snapshot. Table '%s' not found in template", table)
+		}
+		records, err := decodeRecords(fsm, raws)
+		if err != nil {
+			return err
+		}
+		if tables == nil {
+			tables = make(map[string][]map[string]interface{})
+		}
+		tables[table] = records
+	}
+	t.cur_state_name = snapshot.State
+	t.stack = snapshot.Stack
+	t.line_num = snapshot.LineNum
+	t.partial = []byte(snapshot.Partial)
+	t.closed = snapshot.Closed
+	t.values = values
+	t.children = children
+	t.Dict = dict
+	t.Tables = tables
+	t.initTables(fsm)
+	t.Diagnostics = snapshot.Diagnostics
+	t.Unmatched = snapshot.Unmatched
+	return nil
+}
+
+// decodeRecords converts JSON of records back to records.
+// Keys that are names of levels hold the records of the level (See 'Level=' option).
+func decodeRecords(fsm TextFSM, raws []map[string]json.RawMessage) ([]map[string]interface{}, error) {
+	records := make([]map[string]interface{}, 0, len(raws))
+	for _, rec := range raws {
+		record := make(map[string]interface{})
+		for name, raw := range rec {
+			if _, exists := fsm.levels[name]; exists {
+				nested := make([]map[string]json.RawMessage, 0)
+				if err := json.Unmarshal(raw, &nested); err != nil {
+					return nil, fmt.Errorf("Invalid snapshot. Level '%s': %s", name, err.Error())
+				}
+				val, err := decodeRecords(fsm, nested)
+				if err != nil {
+					return nil, err
+				}
+				record[name] = val
+				continue
+			}
+			value, exists := fsm.Values[name]
+			if !exists {
+				return nil, fmt.Errorf("Invalid snapshot. Value '%s' not found in template", name)
+			}
+			val, err := value.decodeValue(raw)
+			if err != nil {
+				return nil, err
+			}
+			record[name] = val
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// decodeValue converts JSON of a value back to its concrete type.
+// The concrete type (string, []string, map[string]string or []map[string]string) depends on the definition of the value.
+// JSON null is returned as nil.
+func (v *TextFSMValue) decodeValue(raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var err error
+	var val interface{}
+	is_list := FindIndex(v.Options, "List") >= 0
+	is_map := v.isMap()
+	switch {
+	case is_list && is_map:
+		decoded := make([]map[string]string, 0)
+		err = json.Unmarshal(raw, &decoded)
+		val = decoded
+	case is_list:
+		decoded := make([]string, 0)
+		err = json.Unmarshal(raw, &decoded)
+		val = decoded
+	case is_map:
+		decoded := make(map[string]string)
+		err = json.Unmarshal(raw, &decoded)
+		val = decoded
+	default:
+		decoded := ""
+		err = json.Unmarshal(raw, &decoded)
+		val = decoded
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid snapshot. Value '%s': %s", v.Name, err.Error())
+	}
+	return val, nil
+}
@@ -0,0 +1,143 @@
+package gotextfsm
+
+import (
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	count := 0
+	for _, tc := range parseTestCases {
+		if tc.compile_err != nil || tc.reset != nil || tc.data1 != "" || (tc.eof != nil && !*tc.eof) {
+			continue
+		}
+		fsm := TextFSM{}
+		if err := fsm.ParseString(tc.template); err != nil {
+			t.Errorf("'%s' failed. TextFSM should be valid. But got error '%s'", tc.name, err.Error())
+			continue
+		}
+		expected := ParserOutput{}
+		experr := expected.ParseTextString(tc.data, fsm, true)
+		count++
+		// Take a snapshot after every chunk and continue parsing with a new ParserOutput restored from it.
+		out := &ParserOutput{}
+		var err error
+		for i := 0; i < len(tc.data) && err == nil; i += 5 {
+			end := i + 5
+			if end > len(tc.data) {
+				end = len(tc.data)
+			}
+			if err = out.ParseChunk(tc.data[i:end], fsm); err != nil {
+				break
+			}
+			var data []byte
+			if data, err = out.Snapshot(); err != nil {
+				t.Errorf("'%s' failed. Snapshot error '%s'", tc.name, err)
+				break
+			}
+			out = &ParserOutput{}
+			if err = out.Restore(data, fsm); err != nil {
+				t.Errorf("'%s' failed. Restore error '%s'", tc.name, err)
+				break
+			}
+		}
+		if err == nil {
+			err = out.Close(fsm)
+		}
+		if (experr == nil) != (err == nil) {
+			t.Errorf("'%s' failed. Errors dont match ('%v', '%v')", tc.name, experr, err)
+			continue
+		}
+		if len(expected.Dict) != len(out.Dict) {
+			t.Errorf("'%s' failed. Expected %d records. Got %d records", tc.name, len(expected.Dict), len(out.Dict))
+			continue
+		}
+		for idx, exprec := range expected.Dict {
+			if msg := comparedicts(tc, exprec, out.Dict[idx], idx); msg != "" {
+				t.Error(msg)
+				break
+			}
+		}
+	}
+	t.Logf("Executed %d test cases", count)
+}
+
+func TestSnapshotState(t *testing.T) {
+	template := `Value Filldown device (\S+)
+Value interface (\S+)
+Value List vlans (\d+)
+Value address ((?P<ip>[\d.]+)/(?P<mask>\d+))
+
+Start
+  ^Device: ${device} -> Interfaces
+
+Interfaces
+  ^Interface ${interface}
+  ^\s+vlan ${vlans}
+  ^\s+address ${address}
+  ^$$ -> Record
+`
+	fsm := TextFSM{}
+	if err := fsm.ParseString(template); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	out := ParserOutput{}
+	if err := out.ParseChunk("Device: sw1\nInterface eth0\n  vlan 10\n\nInterface eth1\n  vlan 20\n  address 10.0.0.1/24\n  vl", fsm); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	data, err := out.Snapshot()
+	if err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	restored := ParserOutput{}
+	if err := restored.Restore(data, fsm); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	if restored.cur_state_name != "Interfaces" || restored.line_num != 7 || string(restored.partial) != "  vl" {
+		t.Errorf("State dont match. Got state '%s', line %d, partial '%s'", restored.cur_state_name, restored.line_num, restored.partial)
+	}
+	if len(restored.Dict) != 1 || !valsEquals(restored.Dict[0]["vlans"], []string{"10"}) {
+		t.Errorf("Records dont match. Got %v", restored.Dict)
+	}
+	if val := restored.values["address"].curval; !valsEquals(val, map[string]string{"ip": "10.0.0.1", "mask": "24"}) {
+		t.Errorf("Value 'address' dont match. Got %v", val)
+	}
+	if val := restored.values["device"].filldown_value; !valsEquals(val, "sw1") {
+		t.Errorf("Filldown value dont match. Got %v", val)
+	}
+	if err := restored.ParseChunk("an 30\n", fsm); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	if err := restored.Close(fsm); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	expected := map[string]interface{}{
+		"device":    "sw1",
+		"interface": "eth1",
+		"vlans":     []string{"20", "30"},
+		"address":   map[string]string{"ip": "10.0.0.1", "mask": "24"},
+	}
+	if len(restored.Dict) != 2 {
+		t.Fatalf("Expected 2 records. Got %v", restored.Dict)
+	}
+	if msg := comparedicts(parseTestCase{name: "Snapshot"}, expected, restored.Dict[1], 1); msg != "" {
+		t.Error(msg)
+	}
+
+	invalid := []string{
+		`not json`,
+		`{"state": "Unknown"}`,
+		`{"state": "Start", "values": {"unknown": "x"}}`,
+		`{"state": "Start", "values": {"vlans": "x"}}`,
+		`{"state": "Start", "filldown": {"device": ["x"]}}`,
+		`{"state": "Start", "records": [{"address": "x"}]}`,
+	}
+	for _, data := range invalid {
+		if err := restored.Restore([]byte(data), fsm); err == nil {
+			t.Errorf("'%s' failed. Expected error, but none found", data)
+		}
+	}
+	// Failed restores leave ParserOutput unchanged.
+	if len(restored.Dict) != 2 || restored.line_num != 8 {
+		t.Errorf("ParserOutput should be unchanged. Got %d records at line %d", len(restored.Dict), restored.line_num)
+	}
+}
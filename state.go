@@ -1,7 +1,21 @@
 package gotextfsm
 
 type TextFSMState struct {
-	name  string
-	rules []TextFSMRule
-	fsm   *TextFSM
+	name    string
+	rules   []TextFSMRule
+	fsm     *TextFSM
+	matcher *literalMatcher
+}
+
+// Name returns the name of the state.
+func (s *TextFSMState) Name() string {
+	return s.name
+}
+
+// Rules returns the rules of the state in the order they were declared in the template.
+// The returned slice is a copy. Modifying it does not affect the state.
+func (s *TextFSMState) Rules() []TextFSMRule {
+	rules := make([]TextFSMRule, len(s.rules))
+	copy(rules, s.rules)
+	return rules
 }
@@ -0,0 +1,290 @@
+package gotextfsm
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Span is the range [Start, End) of bytes of the source of a template.
+type Span struct {
+	Start int
+	End   int
+}
+
+// TokenKind is the kind of a token of a template. See SyntaxToken.
+type TokenKind int
+
+const (
+	TokenWhitespace TokenKind = iota // Indentation and spaces between (and after) the other tokens.
+	TokenNewline                     // '\n' or '\r\n' at the end of a line.
+	TokenComment                     // Comment, from '#' to the end of the line.
+	TokenKeyword                     // 'Value', 'Include', 'Macro', 'Extends', '@before' or '@after'.
+	TokenOptions                     // Options of a Value. ex. 'Required,Filldown'
+	TokenName                        // Name of a Value, a macro or a state. Label of '@before' and '@after'.
+	TokenRegex                       // Regex of a Value, a macro or a rule.
+	TokenPath                        // Path of 'Include' and 'Extends'.
+	TokenArrow                       // '->' of a rule.
+	TokenAction                      // A word of the action of a rule. ex. 'Next.Record', 'Call', 'Start'
+	TokenText                        // Text of a line that is not valid where it is.
+)
+
+var token_kind_names = []string{"Whitespace", "Newline", "Comment", "Keyword", "Options", "Name", "Regex", "Path", "Arrow", "Action", "Text"}
+
+func (k TokenKind) String() string {
+	return token_kind_names[k]
+}
+
+// SyntaxToken is a token of a template, with its text and its span in the source.
+type SyntaxToken struct {
+	Kind TokenKind
+	Text string
+	Span Span
+}
+
+// LineKind is the kind of a line of a template. See SyntaxLine.
+type LineKind int
+
+const (
+	LineBlank     LineKind = iota // Empty line, or a line of whitespace only.
+	LineComment                   // Comment line.
+	LineValue                     // Value definition. ex. 'Value Required Interface (\S+)'
+	LineDirective                 // 'Include', 'Macro' or 'Extends' line. See ParseFS.
+	LineState                     // Name of a state, or '@before'/'@after' of a template that extends another.
+	LineRule                      // Rule of a state. ex. '  ^interface ${Interface} -> Record'
+	LineInvalid                   // Line that is not valid where it is. ex. A rule among the Values.
+)
+
+var line_kind_names = []string{"Blank", "Comment", "Value", "Directive", "State", "Rule", "Invalid"}
+
+func (k LineKind) String() string {
+	return line_kind_names[k]
+}
+
+// SyntaxLine is a line of a template. The tokens cover every byte of the line, including its newline.
+type SyntaxLine struct {
+	Kind   LineKind
+	Number int  // Line number, starting from 1.
+	Span   Span // Span of the line, including its newline.
+	Tokens []SyntaxToken
+}
+
+// Token returns the first token of the line of the kind. false if there is none.
+func (l *SyntaxLine) Token(kind TokenKind) (SyntaxToken, bool) {
+	for _, token := range l.Tokens {
+		if token.Kind == kind {
+			return token, true
+		}
+	}
+	return SyntaxToken{}, false
+}
+
+// SyntaxTree is a lossless syntax tree of a template. Unlike TextFSM, it keeps the comments, blank lines and whitespace.
+// The text of its tokens, in order, is the source of the template byte for byte. See String.
+// Lines are classified the way ParseString (and ParseFS, for the directives) reads them. But they are not validated.
+// ex. A Value with an invalid regex is still a LineValue. So that formatters, linters and editors can work on any template.
+type SyntaxTree struct {
+	Source string
+	Lines  []SyntaxLine
+}
+
+// Separator of the match and the action of a rule. Same as the one in TextFSMRule.Parse.
+var RULE_ACTION_RE = regexp.MustCompile(`(.*)(\s->(.*))`)
+
+// ParseSyntax parses the template into a SyntaxTree. It never fails.
+func ParseSyntax(source string) *SyntaxTree {
+	tree := &SyntaxTree{Source: source, Lines: make([]SyntaxLine, 0)}
+	in_values := true
+	in_state := false
+	for start, number := 0, 1; start < len(source); number++ {
+		end := strings.IndexByte(source[start:], '\n')
+		if end < 0 {
+			end = len(source)
+		} else {
+			end += start + 1
+		}
+		builder := syntaxBuilder{source: source, pos: start}
+		newline := end
+		if strings.HasSuffix(source[start:end], "\r\n") {
+			newline -= 2
+		} else if strings.HasSuffix(source[start:end], "\n") {
+			newline -= 1
+		}
+		// Text of the line, without the whitespace after it.
+		text_end := start + len(TrimRightSpace(source[start:newline]))
+		text := source[start:text_end]
+		line := SyntaxLine{Number: number, Span: Span{start, end}}
+		switch {
+		case text == "":
+			line.Kind = LineBlank
+			in_values = false
+			in_state = false
+		case strings.HasPrefix(strings.TrimSpace(text), "#"):
+			line.Kind = LineComment
+			builder.add(TokenComment, start+strings.IndexByte(text, '#'), text_end)
+		case strings.HasPrefix(text, "Value "):
+			line.Kind = LineValue
+			if !in_values {
+				line.Kind = LineInvalid
+			}
+			builder.addValue(start, text)
+		case strings.HasPrefix(text, "Include ") || strings.HasPrefix(text, "Extends "):
+			line.Kind = LineDirective
+			builder.addFields(start, text, TokenKeyword, TokenPath)
+		case strings.HasPrefix(text, "Macro "):
+			line.Kind = LineDirective
+			builder.addFields(start, text, TokenKeyword, TokenName, TokenRegex)
+		case in_values:
+			line.Kind = LineInvalid
+			builder.add(TokenText, start+strings.IndexFunc(text, isNotSpace), text_end)
+		case !in_state && INSERT_RE.MatchString(text):
+			line.Kind = LineState
+			in_state = true
+			builder.addFields(start, text, TokenKeyword, TokenName)
+		case !in_state && !unicode.IsSpace(rune(text[0])):
+			line.Kind = LineState
+			in_state = true
+			builder.add(TokenName, start, text_end)
+		case in_state && isRule(text):
+			line.Kind = LineRule
+			builder.addRule(start, text)
+		default:
+			line.Kind = LineInvalid
+			builder.add(TokenText, start+strings.IndexFunc(text, isNotSpace), text_end)
+		}
+		builder.add(TokenWhitespace, builder.pos, newline)
+		builder.add(TokenNewline, newline, end)
+		line.Tokens = builder.tokens
+		tree.Lines = append(tree.Lines, line)
+		start = end
+	}
+	return tree
+}
+
+// String returns the text of the tokens of the tree. It is the source the tree was parsed from.
+func (t *SyntaxTree) String() string {
+	var sb strings.Builder
+	for _, line := range t.Lines {
+		for _, token := range line.Tokens {
+			sb.WriteString(token.Text)
+		}
+	}
+	return sb.String()
+}
+
+// TokenAt returns the token of the tree at the byte offset of the source. false if the offset is out of the source.
+func (t *SyntaxTree) TokenAt(offset int) (SyntaxToken, bool) {
+	for _, line := range t.Lines {
+		if offset < line.Span.Start || offset >= line.Span.End {
+			continue
+		}
+		for _, token := range line.Tokens {
+			if offset >= token.Span.Start && offset < token.Span.End {
+				return token, true
+			}
+		}
+	}
+	return SyntaxToken{}, false
+}
+
+// syntaxBuilder builds the tokens of a line of the source.
+type syntaxBuilder struct {
+	source string
+	pos    int // End of the last token.
+	tokens []SyntaxToken
+}
+
+// add adds the token [start, end) of the kind. The text between the last token and start is added as whitespace.
+func (b *syntaxBuilder) add(kind TokenKind, start int, end int) {
+	if start > b.pos {
+		b.tokens = append(b.tokens, SyntaxToken{Kind: TokenWhitespace, Text: b.source[b.pos:start], Span: Span{b.pos, start}})
+		b.pos = start
+	}
+	if end > start {
+		b.tokens = append(b.tokens, SyntaxToken{Kind: kind, Text: b.source[start:end], Span: Span{start, end}})
+		b.pos = end
+	}
+}
+
+// addFields adds the fields of the text (at offset start of the source) as tokens of the kinds.
+// The last kind takes the rest of the text, whitespace included.
+func (b *syntaxBuilder) addFields(start int, text string, kinds ...TokenKind) {
+	spans := fieldSpans(text)
+	for i, kind := range kinds {
+		if i >= len(spans) {
+			return
+		}
+		if i == len(kinds)-1 {
+			b.add(kind, start+spans[i].Start, start+len(text))
+			return
+		}
+		b.add(kind, start+spans[i].Start, start+spans[i].End)
+	}
+}
+
+// addValue adds the tokens of a Value line. See TextFSMValue.Parse.
+func (b *syntaxBuilder) addValue(start int, text string) {
+	spans := fieldSpans(text)
+	if len(spans) < 3 {
+		b.addFields(start, text, TokenKeyword, TokenText)
+		return
+	}
+	if strings.HasPrefix(text[spans[2].Start:], "(") {
+		b.addFields(start, text, TokenKeyword, TokenName, TokenRegex)
+		return
+	}
+	b.addFields(start, text, TokenKeyword, TokenOptions, TokenName, TokenRegex)
+}
+
+// addRule adds the tokens of a rule line. See TextFSMRule.Parse.
+func (b *syntaxBuilder) addRule(start int, text string) {
+	indent := strings.IndexFunc(text, isNotSpace)
+	m := RULE_ACTION_RE.FindStringSubmatchIndex(text[indent:])
+	if m == nil {
+		b.add(TokenRegex, start+indent, start+len(text))
+		return
+	}
+	// The separator is a whitespace followed by '->'.
+	match_end := indent + len(TrimRightSpace(text[indent:indent+m[4]]))
+	b.add(TokenRegex, start+indent, start+match_end)
+	arrow := indent + m[4] + 1
+	b.add(TokenArrow, start+arrow, start+arrow+2)
+	action := text[arrow+2:]
+	for _, span := range fieldSpans(action) {
+		b.add(TokenAction, start+arrow+2+span.Start, start+arrow+2+span.End)
+	}
+}
+
+// fieldSpans returns the spans of the fields of the text, as split by strings.Fields.
+func fieldSpans(text string) []Span {
+	spans := make([]Span, 0)
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				spans = append(spans, Span{start, i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, Span{start, len(text)})
+	}
+	return spans
+}
+
+// isRule returns true if the line is a rule, as ParseString reads it. ie. indented with whitespace and starting with '^'.
+func isRule(text string) bool {
+	for _, prefix := range []string{" ^", "  ^", "\t^"} {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNotSpace(r rune) bool {
+	return !unicode.IsSpace(r)
+}
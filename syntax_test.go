@@ -0,0 +1,86 @@
+package gotextfsm
+
+import (
+	"reflect"
+	"testing"
+)
+
+const syntaxTemplate = "# Interfaces\r\nMacro IFNAME \\S+\nValue Required,Filldown  Interface  (${IFNAME})\nValue Status (up|down  admin)  \n\n" +
+	"Start\n  # Header\n  ^interface ${Interface} is ${Status}   ->  Continue.Record Other\n\t^\\s*$$\n bad\n^unindented\n\nOther\n  ^. -> Start\n\n@after interface\n  ^x"
+
+func TestParseSyntax(t *testing.T) {
+	tree := ParseSyntax(syntaxTemplate)
+	if tree.String() != syntaxTemplate {
+		t.Fatalf("Source dont match ('%q', '%q')", syntaxTemplate, tree.String())
+	}
+	kinds := []LineKind{
+		LineComment, LineDirective, LineValue, LineValue, LineBlank,
+		LineState, LineComment, LineRule, LineRule, LineInvalid, LineInvalid, LineBlank,
+		LineState, LineRule, LineBlank, LineState, LineRule,
+	}
+	if len(tree.Lines) != len(kinds) {
+		t.Fatalf("Number of lines dont match (%d, %d)", len(kinds), len(tree.Lines))
+	}
+	for i, line := range tree.Lines {
+		if line.Kind != kinds[i] {
+			t.Errorf("Kind of line %d dont match (%s, %s)", line.Number, kinds[i], line.Kind)
+		}
+		if line.Number != i+1 {
+			t.Errorf("Number of line %d dont match (%d)", i+1, line.Number)
+		}
+		// Tokens cover the line, and their spans point to their text.
+		pos := line.Span.Start
+		for _, token := range line.Tokens {
+			if token.Span.Start != pos || syntaxTemplate[token.Span.Start:token.Span.End] != token.Text {
+				t.Errorf("Span of token '%s' of line %d dont match (%v)", token.Text, line.Number, token.Span)
+			}
+			pos = token.Span.End
+		}
+		if pos != line.Span.End {
+			t.Errorf("Tokens of line %d dont cover the line (%d, %d)", line.Number, line.Span.End, pos)
+		}
+	}
+	cases := []struct {
+		line   int
+		tokens []string
+	}{
+		{line: 1, tokens: []string{"Comment:# Interfaces", "Newline:\r\n"}},
+		{line: 2, tokens: []string{"Keyword:Macro", "Whitespace: ", "Name:IFNAME", "Whitespace: ", "Regex:\\S+", "Newline:\n"}},
+		{line: 3, tokens: []string{"Keyword:Value", "Whitespace: ", "Options:Required,Filldown", "Whitespace:  ", "Name:Interface", "Whitespace:  ", "Regex:(${IFNAME})", "Newline:\n"}},
+		{line: 4, tokens: []string{"Keyword:Value", "Whitespace: ", "Name:Status", "Whitespace: ", "Regex:(up|down  admin)", "Whitespace:  ", "Newline:\n"}},
+		{line: 5, tokens: []string{"Newline:\n"}},
+		{line: 8, tokens: []string{"Whitespace:  ", "Regex:^interface ${Interface} is ${Status}", "Whitespace:   ", "Arrow:->", "Whitespace:  ", "Action:Continue.Record", "Whitespace: ", "Action:Other", "Newline:\n"}},
+		{line: 9, tokens: []string{"Whitespace:\t", "Regex:^\\s*$$", "Newline:\n"}},
+		{line: 10, tokens: []string{"Whitespace: ", "Text:bad", "Newline:\n"}},
+		{line: 11, tokens: []string{"Text:^unindented", "Newline:\n"}},
+		{line: 16, tokens: []string{"Keyword:@after", "Whitespace: ", "Name:interface", "Newline:\n"}},
+		{line: 17, tokens: []string{"Whitespace:  ", "Regex:^x"}},
+	}
+	for _, tc := range cases {
+		tokens := make([]string, 0)
+		for _, token := range tree.Lines[tc.line-1].Tokens {
+			tokens = append(tokens, token.Kind.String()+":"+token.Text)
+		}
+		if !reflect.DeepEqual(tokens, tc.tokens) {
+			t.Errorf("Tokens of line %d dont match (%q, %q)", tc.line, tc.tokens, tokens)
+		}
+	}
+	if token, ok := tree.TokenAt(len("# Interfaces\r\nMacro IF")); !ok || token.Kind != TokenName || token.Text != "IFNAME" {
+		t.Errorf("Token at offset dont match (%v)", token)
+	}
+	if _, ok := tree.TokenAt(len(syntaxTemplate)); ok {
+		t.Errorf("Token found after the end of the source")
+	}
+	if token, ok := tree.Lines[2].Token(TokenRegex); !ok || token.Text != "(${IFNAME})" {
+		t.Errorf("Regex of the Value dont match (%v)", token)
+	}
+}
+
+func TestParseSyntaxRoundTrip(t *testing.T) {
+	sources := []string{"", "\n", "\r\n\r\n", "Value", "Value Foo (a  b)\n\nStart\n  ^${Foo}\n", "  \t \n#", "Start\r\n ^a -> \r\n"}
+	for _, source := range sources {
+		if tree := ParseSyntax(source); tree.String() != source {
+			t.Errorf("'%q' failed. Source dont match ('%q')", source, tree.String())
+		}
+	}
+}
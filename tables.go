@@ -0,0 +1,104 @@
+package gotextfsm
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Name of a table.
+var TABLE_RE = regexp.MustCompile(`^\w+$`)
+
+// parseTables collects the tables of the Values (See 'Table=' option) in the order they were declared.
+// The name of a table is the target of 'Record' operator. Hence, it can not be the name of a Value or a level.
+func (t *TextFSM) parseTables() error {
+	t.table_names = make([]string, 0)
+	for _, name := range t.value_names {
+		table := t.Values[name].Table
+		if table == "" || FindIndex(t.table_names, table) >= 0 {
+			continue
+		}
+		if _, exists := t.Values[table]; exists {
+			return fmt.Errorf("Table '%s' has the same name as a Value", table)
+		}
+		if _, exists := t.levels[table]; exists {
+			return fmt.Errorf("Table '%s' has the same name as a level", table)
+		}
+		t.table_names = append(t.table_names, table)
+	}
+	return nil
+}
+
+// TableNames returns the names of the tables in the order they were declared in the template.
+// The returned slice is a copy and can be modified by the caller.
+func (t *TextFSM) TableNames() []string {
+	return append([]string{}, t.table_names...)
+}
+
+// TableFSM returns a copy of the template with only the Values of the table, as the Values of the default table.
+// It is meant for the encoders. ex. WriteCSV(w, fsm.TableFSM("Neighbors"), output.Tables["Neighbors"], options)
+// Returns false if there is no such table.
+func (t *TextFSM) TableFSM(table string) (TextFSM, bool) {
+	if FindIndex(t.table_names, table) < 0 {
+		return TextFSM{}, false
+	}
+	output := *t
+	output.Values = make(map[string]TextFSMValue)
+	output.value_names = make([]string, 0)
+	output.levels = make(map[string]string)
+	output.level_paths = make([]string, 0)
+	output.table_names = make([]string, 0)
+	for _, name := range t.tableValueNames(table) {
+		value := t.Values[name]
+		value.Table = ""
+		output.Values[name] = value
+		output.value_names = append(output.value_names, name)
+	}
+	return output, true
+}
+
+// tableValueNames returns the names of the Values of the table, in the order they were declared.
+func (t *TextFSM) tableValueNames(table string) []string {
+	names := make([]string, 0)
+	for _, name := range t.value_names {
+		if t.Values[name].Table == table {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// initTables creates the result set of each table, if it is not already done.
+func (t *ParserOutput) initTables(fsm TextFSM) {
+	if t.Tables != nil || len(fsm.table_names) == 0 {
+		return
+	}
+	t.Tables = make(map[string][]map[string]interface{}, len(fsm.table_names))
+	for _, table := range fsm.table_names {
+		t.Tables[table] = make([]map[string]interface{}, 0)
+	}
+}
+
+// recordTable adds the current record of the table to the table if well formed.
+func (t *ParserOutput) recordTable(fsm TextFSM, table string) error {
+	newmap, any_value, err := t.buildRecord(fsm.tableValueNames(table))
+	if err != nil {
+		return err
+	}
+	if newmap != nil && any_value {
+		t.Tables[table] = append(t.Tables[table], newmap)
+	}
+	t.clearTable(fsm, table, false)
+	return nil
+}
+
+// clearTable clears the values of the table. Filldown values are cleared too if all is true.
+func (t *ParserOutput) clearTable(fsm TextFSM, table string, all bool) {
+	for name, value := range t.values {
+		if value.Table != table {
+			continue
+		}
+		value.clearValue(all)
+		// value is a copy of the value in the map. Store the modified copy back.
+		t.values[name] = value
+	}
+}
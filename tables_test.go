@@ -0,0 +1,183 @@
+package gotextfsm
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const tablesTemplate = `Value RouterId (\S+)
+Value LocalAs (\d+)
+Value Table=Neighbors Neighbor (\d+\.\d+\.\d+\.\d+)
+Value Table=Neighbors RemoteAs (\d+)
+Value Table=Neighbors State (\S+)
+Value Key,Table=Counters Name (\w+)
+Value Table=Counters Count (\d+)
+
+Start
+  ^BGP router identifier ${RouterId}, local AS number ${LocalAs} -> Record
+  ^${Name}: ${Count} -> Record Counters
+  ^Neighbor -> Table
+
+Table
+  ^${Neighbor}\s+\d+\s+${RemoteAs}\s+${State} -> Record Neighbors
+`
+
+const tablesData = `BGP router identifier 10.0.0.1, local AS number 65000
+Updates: 10
+Withdraws: 2
+Neighbor        V    AS    State
+10.0.0.2        4 65001    Established
+10.0.0.3        4 65002    Idle
+`
+
+func TestTables(t *testing.T) {
+	fsm := TextFSM{}
+	if err := fsm.ParseString(tablesTemplate); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	if !reflect.DeepEqual(fsm.TableNames(), []string{"Neighbors", "Counters"}) {
+		t.Errorf("Table names dont match (%v, %v)", []string{"Neighbors", "Counters"}, fsm.TableNames())
+	}
+	out := ParserOutput{}
+	if err := out.ParseTextString(tablesData, fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	expected := []map[string]interface{}{
+		{"RouterId": "10.0.0.1", "LocalAs": "65000"},
+	}
+	if !reflect.DeepEqual(out.Dict, expected) {
+		t.Errorf("Records dont match (%v, %v)", expected, out.Dict)
+	}
+	tables := map[string][]map[string]interface{}{
+		"Neighbors": {
+			{"Neighbor": "10.0.0.2", "RemoteAs": "65001", "State": "Established"},
+			{"Neighbor": "10.0.0.3", "RemoteAs": "65002", "State": "Idle"},
+		},
+		"Counters": {
+			{"Name": "Updates", "Count": "10"},
+			{"Name": "Withdraws", "Count": "2"},
+		},
+	}
+	if !reflect.DeepEqual(out.Tables, tables) {
+		t.Errorf("Tables dont match (%v, %v)", tables, out.Tables)
+	}
+
+	// Snapshot and restore in the middle of the tables.
+	lines := strings.SplitAfter(tablesData, "\n")
+	first := ParserOutput{}
+	if err := first.ParseTextString(strings.Join(lines[:5], ""), fsm, false); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	data, err := first.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed with error '%s'", err)
+	}
+	restored := ParserOutput{}
+	if err := restored.Restore(data, fsm); err != nil {
+		t.Fatalf("Restore failed with error '%s'", err)
+	}
+	if err := restored.ParseTextString(strings.Join(lines[5:], ""), fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	if !reflect.DeepEqual(restored.Tables, tables) || !reflect.DeepEqual(restored.Dict, expected) {
+		t.Errorf("Restored records dont match (%v, %v)", tables, restored.Tables)
+	}
+
+	neighbors, exists := fsm.TableFSM("Neighbors")
+	if !exists {
+		t.Fatalf("Table 'Neighbors' not found")
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, neighbors, out.Tables["Neighbors"], CSVOptions{}); err != nil {
+		t.Fatalf("WriteCSV failed with error '%s'", err)
+	}
+	csv := "Neighbor,RemoteAs,State\n10.0.0.2,65001,Established\n10.0.0.3,65002,Idle\n"
+	if buf.String() != csv {
+		t.Errorf("CSV dont match ('%s', '%s')", csv, buf.String())
+	}
+	buf.Reset()
+	if err := WriteCSV(&buf, fsm, out.Dict, CSVOptions{}); err != nil {
+		t.Fatalf("WriteCSV failed with error '%s'", err)
+	}
+	if buf.String() != "RouterId,LocalAs\n10.0.0.1,65000\n" {
+		t.Errorf("CSV dont match ('%s')", buf.String())
+	}
+}
+
+func TestTablesImplicitEOF(t *testing.T) {
+	// The implicit record at EOF applies to all the tables.
+	template := `Value Name (\S+)
+Value Table=Extras Extra (\S+)
+
+Start
+  ^name ${Name}
+  ^extra ${Extra}
+`
+	fsm := TextFSM{}
+	if err := fsm.ParseString(template); err != nil {
+		t.Fatalf("Template parsing failed with error '%s'", err)
+	}
+	out := ParserOutput{}
+	if err := out.ParseTextString("name foo\nextra bar\n", fsm, true); err != nil {
+		t.Fatalf("Parsing failed with error '%s'", err)
+	}
+	if !reflect.DeepEqual(out.Dict, []map[string]interface{}{{"Name": "foo"}}) {
+		t.Errorf("Records dont match (%v)", out.Dict)
+	}
+	if !reflect.DeepEqual(out.Tables, map[string][]map[string]interface{}{"Extras": {{"Extra": "bar"}}}) {
+		t.Errorf("Tables dont match (%v)", out.Tables)
+	}
+}
+
+func TestTablesTemplateErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		template string
+		err      string
+	}{
+		{
+			name:     "Invalid table",
+			template: "Value Table=a.b Foo (\\S+)\n\nStart\n  ^${Foo}\n",
+			err:      "Line 1: Invalid table 'a.b'",
+		},
+		{
+			name:     "Duplicate table option",
+			template: "Value Table=a,Table=b Foo (\\S+)\n\nStart\n  ^${Foo}\n",
+			err:      "1 Line: Duplicate option Table",
+		},
+		{
+			name:     "Fillup with table",
+			template: "Value Table=a,Fillup Foo (\\S+)\n\nStart\n  ^${Foo}\n",
+			err:      "Line 1: Option Fillup can not be used with Table",
+		},
+		{
+			name:     "Level and table",
+			template: "Value Table=a,Level=b Foo (\\S+)\n\nStart\n  ^${Foo}\n",
+			err:      "Line 1: Options Level and Table can not be used together",
+		},
+		{
+			name:     "Table with the name of a Value",
+			template: "Value Table=Bar Foo (\\S+)\nValue Bar (\\S+)\n\nStart\n  ^${Foo}\n",
+			err:      "Table 'Bar' has the same name as a Value",
+		},
+		{
+			name:     "Table with the name of a level",
+			template: "Value Table=a Foo (\\S+)\nValue Level=a Bar (\\S+)\n\nStart\n  ^${Foo}\n",
+			err:      "Table 'a' has the same name as a level",
+		},
+		{
+			name:     "State with the name of a table",
+			template: "Value Table=a Foo (\\S+)\n\nStart\n  ^${Foo}\n\na\n  ^x\n",
+			err:      "6 Line: state 'a' has the same name as a table",
+		},
+	}
+	for _, tc := range cases {
+		fsm := TextFSM{}
+		err := fsm.ParseString(tc.template)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("'%s' failed. Errors dont match ('%s', '%v')", tc.name, tc.err, err)
+		}
+	}
+}
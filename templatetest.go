@@ -0,0 +1,490 @@
+package gotextfsm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// TemplateTestCase is a test of a template: An input to parse and the records expected from it.
+//     Template: Path of the template (.textfsm)
+//     Input: Path of the input (.raw)
+//     Expected: Path of the expected records (.yml, .yaml or .json). Empty if there is none.
+type TemplateTestCase struct {
+	Template string
+	Input    string
+	Expected string
+}
+
+// TemplateTestOptions controls how the template tests are run.
+//     LowercaseKeys: Compare the names of the values in lower case.
+//                    ntc-templates for example uses upper case Value names but lower case keys in the expected records.
+type TemplateTestOptions struct {
+	LowercaseKeys bool
+}
+
+// TemplateTestResult is the result of running a TemplateTestCase.
+//     Err: Error while parsing the template, the input or the expected records. nil otherwise.
+//     Diffs: Differences between the expected and the parsed records. Empty if they are same.
+//     Records: Records parsed from the input.
+//     Updated: Expected records were rewritten with the parsed records. (See UpdateTemplateTests)
+type TemplateTestResult struct {
+	Case    TemplateTestCase
+	Err     error
+	Diffs   []string
+	Records []map[string]interface{}
+	Updated bool
+	fsm     TextFSM
+}
+
+// Passed returns true if the parsed records are same as the expected records.
+func (r *TemplateTestResult) Passed() bool {
+	return r.Err == nil && len(r.Diffs) == 0
+}
+
+var expectedExtensions = []string{".yml", ".yaml", ".json"}
+
+// DiscoverTemplateTests finds the template tests in fsys by naming convention.
+//
+// Every input file (.raw) is a test case. Its expected records are in the file with the same name,
+// with extension .yml, .yaml or .json. The template of an input is found as follows:
+//   * Template next to the input, with the same name as the input or the directory of the input.
+//     ex. show_version.textfsm with show_version.raw or show_version/*.raw
+//   * ntc-templates layout. Template templates/<platform>_<command>.textfsm for tests/<platform>/<command>/*.raw
+//
+// Expected records are either a list of records, or a mapping with the list of records under the key
+// 'parsed_sample' (like ntc-templates).
+//     Args:
+//       fsys: (fs.FS), File system to search.
+//     Returns:
+//       Test cases sorted by input path. error if the file system can not be read.
+func DiscoverTemplateTests(fsys fs.FS) ([]TemplateTestCase, error) {
+	cases := make([]TemplateTestCase, 0)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != ".raw" {
+			return nil
+		}
+		base := strings.TrimSuffix(p, ".raw")
+		tc := TemplateTestCase{Input: p}
+		for _, ext := range expectedExtensions {
+			if fileExists(fsys, base+ext) {
+				tc.Expected = base + ext
+				break
+			}
+		}
+		for _, candidate := range templateCandidates(p) {
+			if fileExists(fsys, candidate) {
+				tc.Template = candidate
+				break
+			}
+		}
+		cases = append(cases, tc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Input < cases[j].Input })
+	return cases, nil
+}
+
+// templateCandidates returns the possible paths of the template of an input, in the order of preference.
+func templateCandidates(input string) []string {
+	dir := path.Dir(input)
+	candidates := []string{strings.TrimSuffix(input, ".raw") + ".textfsm"}
+	if dir != "." {
+		candidates = append(candidates, dir+".textfsm")
+	}
+	// ntc-templates layout: [root/]tests/<platform>/<command>/<input>.raw
+	parts := strings.Split(dir, "/")
+	if len(parts) >= 3 && parts[len(parts)-3] == "tests" {
+		root := path.Join(parts[:len(parts)-3]...)
+		name := parts[len(parts)-2] + "_" + parts[len(parts)-1] + ".textfsm"
+		candidates = append(candidates, path.Join(root, "templates", name))
+	}
+	return candidates
+}
+
+func fileExists(fsys fs.FS, name string) bool {
+	info, err := fs.Stat(fsys, name)
+	return err == nil && !info.IsDir()
+}
+
+// RunTemplateTests discovers the template tests in fsys and runs them.
+//     Returns:
+//       Results of the test cases. error if the tests can not be discovered.
+func RunTemplateTests(fsys fs.FS, options TemplateTestOptions) ([]TemplateTestResult, error) {
+	cases, err := DiscoverTemplateTests(fsys)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]TemplateTestResult, 0, len(cases))
+	for _, tc := range cases {
+		results = append(results, RunTemplateTest(fsys, tc, options))
+	}
+	return results, nil
+}
+
+// RunTemplateTest parses the input of the test case with its template and compares the records with the expected records.
+func RunTemplateTest(fsys fs.FS, tc TemplateTestCase, options TemplateTestOptions) TemplateTestResult {
+	result := TemplateTestResult{Case: tc}
+	if tc.Template == "" {
+		result.Err = fmt.Errorf("No template found for '%s'", tc.Input)
+		return result
+	}
+	fsm := TextFSM{}
+	// Errors in the template are reported with the file name.
+	if err := fsm.ParseFS(fsys, tc.Template); err != nil {
+		result.Err = err
+		return result
+	}
+	input, err := fs.ReadFile(fsys, tc.Input)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	out := ParserOutput{}
+	if err := out.ParseTextString(string(input), fsm, true); err != nil {
+		result.Err = fmt.Errorf("%s: %s", tc.Input, err.Error())
+		return result
+	}
+	result.fsm = fsm
+	result.Records = out.Dict
+	if options.LowercaseKeys {
+		result.Records = lowercaseKeys(out.Dict)
+		result.fsm = lowercaseFSM(fsm)
+	}
+	if tc.Expected == "" {
+		result.Err = fmt.Errorf("No expected records found for '%s'", tc.Input)
+		return result
+	}
+	expected, err := readExpectedRecords(fsys, tc.Expected)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Diffs = diffRecords(expected, result.Records)
+	return result
+}
+
+// lowercaseFSM returns a copy of the fsm with the Values in lower case. Used to write records with lower case keys
+// in the order of the Values.
+func lowercaseFSM(fsm TextFSM) TextFSM {
+	output := TextFSM{Values: make(map[string]TextFSMValue), value_names: make([]string, 0)}
+	for _, name := range fsm.value_names {
+		lower := strings.ToLower(name)
+		output.Values[lower] = fsm.Values[name]
+		output.value_names = append(output.value_names, lower)
+	}
+	return output
+}
+
+func lowercaseKeys(records []map[string]interface{}) []map[string]interface{} {
+	output := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		rec := make(map[string]interface{})
+		for name, val := range record {
+			if nested, ok := val.([]map[string]interface{}); ok {
+				val = lowercaseKeys(nested)
+			}
+			rec[strings.ToLower(name)] = val
+		}
+		output = append(output, rec)
+	}
+	return output
+}
+
+// readExpectedRecords reads the expected records from a YAML or JSON file.
+// Values are returned as generic types. (string, []interface{} and map[string]interface{})
+func readExpectedRecords(fsys fs.FS, name string) ([]map[string]interface{}, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if path.Ext(name) == ".json" {
+		err = json.Unmarshal(data, &doc)
+	} else {
+		doc, err = parseYAML(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err.Error())
+	}
+	if mapping, ok := doc.(map[string]interface{}); ok {
+		sample, exists := mapping["parsed_sample"]
+		if !exists {
+			return nil, fmt.Errorf("%s: Expected a list of records", name)
+		}
+		doc = sample
+	}
+	if doc == nil {
+		return make([]map[string]interface{}, 0), nil
+	}
+	list, ok := doc.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: Expected a list of records", name)
+	}
+	records := make([]map[string]interface{}, 0, len(list))
+	for i, item := range list {
+		record, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: Record %d is not a mapping", name, i)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// genericValue converts a parsed value to the generic types of expected records.
+func genericValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case []string:
+		list := make([]interface{}, 0, len(v))
+		for _, elem := range v {
+			list = append(list, elem)
+		}
+		return list
+	case map[string]string:
+		m := make(map[string]interface{})
+		for key, elem := range v {
+			m[key] = elem
+		}
+		return m
+	case []map[string]string:
+		list := make([]interface{}, 0, len(v))
+		for _, elem := range v {
+			list = append(list, genericValue(elem))
+		}
+		return list
+	case []map[string]interface{}:
+		list := make([]interface{}, 0, len(v))
+		for _, elem := range v {
+			m := make(map[string]interface{})
+			for key, field := range elem {
+				m[key] = genericValue(field)
+			}
+			list = append(list, m)
+		}
+		return list
+	}
+	return val
+}
+
+func compactJSON(val interface{}) string {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return string(data)
+}
+
+// diffRecords returns the differences between the expected and the parsed records, compared by position.
+func diffRecords(expected []map[string]interface{}, got []map[string]interface{}) []string {
+	diffs := make([]string, 0)
+	for i := 0; i < len(expected) || i < len(got); i++ {
+		switch {
+		case i >= len(got):
+			diffs = append(diffs, fmt.Sprintf("Record %d: missing %s", i, compactJSON(expected[i])))
+		case i >= len(expected):
+			diffs = append(diffs, fmt.Sprintf("Record %d: extra %s", i, compactJSON(got[i])))
+		default:
+			diffs = append(diffs, diffRecord(i, expected[i], got[i])...)
+		}
+	}
+	return diffs
+}
+
+func diffRecord(idx int, expected map[string]interface{}, got map[string]interface{}) []string {
+	names := make([]string, 0)
+	for name := range expected {
+		names = append(names, name)
+	}
+	for name := range got {
+		if _, exists := expected[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	diffs := make([]string, 0)
+	for _, name := range names {
+		expval, expexists := expected[name]
+		gotval, gotexists := got[name]
+		gotval = genericValue(gotval)
+		switch {
+		case !gotexists:
+			diffs = append(diffs, fmt.Sprintf("Record %d: field '%s' missing. Expected %s", idx, name, compactJSON(expval)))
+		case !expexists:
+			diffs = append(diffs, fmt.Sprintf("Record %d: field '%s' extra. Got %s", idx, name, compactJSON(gotval)))
+		case !reflect.DeepEqual(expval, gotval):
+			diffs = append(diffs, fmt.Sprintf("Record %d: field '%s' changed. Expected %s. Got %s", idx, name, compactJSON(expval), compactJSON(gotval)))
+		}
+	}
+	return diffs
+}
+
+// WriteTemplateTestReport writes a readable report of the results to w.
+// Failed tests are listed with their differences. Passed tests are listed only if verbose is true.
+//     Returns:
+//       true if all the tests passed.
+func WriteTemplateTestReport(w io.Writer, results []TemplateTestResult, verbose bool) bool {
+	failed := 0
+	updated := 0
+	for _, result := range results {
+		if result.Updated {
+			updated++
+			fmt.Fprintf(w, "UPDATED %s (%s)\n", result.Case.Expected, result.Case.Template)
+			continue
+		}
+		if result.Passed() {
+			if verbose {
+				fmt.Fprintf(w, "PASS %s (%s)\n", result.Case.Input, result.Case.Template)
+			}
+			continue
+		}
+		failed++
+		fmt.Fprintf(w, "FAIL %s (%s)\n", result.Case.Input, result.Case.Template)
+		if result.Err != nil {
+			fmt.Fprintf(w, "    %s\n", result.Err.Error())
+		}
+		for _, diff := range result.Diffs {
+			fmt.Fprintf(w, "    %s\n", diff)
+		}
+	}
+	if updated > 0 {
+		fmt.Fprintf(w, "%d passed, %d failed, %d updated\n", len(results)-failed-updated, failed, updated)
+	} else {
+		fmt.Fprintf(w, "%d passed, %d failed\n", len(results)-failed, failed)
+	}
+	return failed == 0
+}
+
+// UpdateTemplateTests runs the template tests in the directory, like RunTemplateTests. The expected records of the
+// tests that fail are then rewritten with the records parsed from their inputs. Tests whose template or input can not
+// be parsed are not updated.
+//
+// Expected records are written in the format of the existing file (.yml, .yaml or .json), keeping the 'parsed_sample'
+// key if the file had it. Tests without expected records get a new .yml file. Records are written with WriteYAML or
+// WriteJSON, so that the values are in the order of the template and the files are stable across updates.
+//     Args:
+//       dir: (string), Directory of the tests.
+//       options: (TemplateTestOptions), See TemplateTestOptions.
+//     Returns:
+//       Results of the test cases, with Updated set for the updated ones. error if the tests can not be discovered.
+func UpdateTemplateTests(dir string, options TemplateTestOptions) ([]TemplateTestResult, error) {
+	fsys := os.DirFS(dir)
+	results, err := RunTemplateTests(fsys, options)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		result := &results[i]
+		if result.Passed() || result.Records == nil {
+			continue
+		}
+		expected := result.Case.Expected
+		wrapped := false
+		if expected == "" {
+			expected = strings.TrimSuffix(result.Case.Input, ".raw") + ".yml"
+		} else if data, err := fs.ReadFile(fsys, expected); err == nil {
+			wrapped = hasParsedSample(expected, data)
+		}
+		var buf bytes.Buffer
+		if path.Ext(expected) == ".json" {
+			err = writeExpectedJSON(&buf, result.fsm, result.Records, wrapped)
+		} else {
+			err = writeExpectedYAML(&buf, result.fsm, result.Records, wrapped)
+		}
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, filepath.FromSlash(expected)), buf.Bytes(), 0644)
+		}
+		if err != nil {
+			result.Err = err
+			continue
+		}
+		result.Case.Expected = expected
+		result.Err = nil
+		result.Diffs = nil
+		result.Updated = true
+	}
+	return results, nil
+}
+
+// hasParsedSample returns true if the expected records in the file are under the key 'parsed_sample'.
+func hasParsedSample(name string, data []byte) bool {
+	var doc interface{}
+	if path.Ext(name) == ".json" {
+		if json.Unmarshal(data, &doc) != nil {
+			return false
+		}
+	} else {
+		var err error
+		if doc, err = parseYAML(string(data)); err != nil {
+			return false
+		}
+	}
+	mapping, ok := doc.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, exists := mapping["parsed_sample"]
+	return exists
+}
+
+func writeExpectedYAML(w io.Writer, fsm TextFSM, records []map[string]interface{}, wrapped bool) error {
+	if !wrapped {
+		return WriteYAML(w, fsm, records)
+	}
+	var buf bytes.Buffer
+	if err := WriteYAML(&buf, fsm, records); err != nil {
+		return err
+	}
+	io.WriteString(w, "---\nparsed_sample:")
+	if len(records) == 0 {
+		_, err := io.WriteString(w, " []\n")
+		return err
+	}
+	io.WriteString(w, "\n")
+	return indentLines(w, buf.String(), "  ")
+}
+
+func writeExpectedJSON(w io.Writer, fsm TextFSM, records []map[string]interface{}, wrapped bool) error {
+	if !wrapped {
+		return WriteJSON(w, fsm, records)
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, fsm, records); err != nil {
+		return err
+	}
+	io.WriteString(w, "{\n  \"parsed_sample\": ")
+	str := strings.TrimSuffix(buf.String(), "\n")
+	lines := strings.Split(str, "\n")
+	io.WriteString(w, lines[0]+"\n")
+	if len(lines) > 1 {
+		indentLines(w, strings.Join(lines[1:], "\n")+"\n", "  ")
+	}
+	_, err := io.WriteString(w, "}\n")
+	return err
+}
+
+func indentLines(w io.Writer, text string, indent string) error {
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		if _, err := io.WriteString(w, indent+line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
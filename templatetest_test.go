@@ -0,0 +1,231 @@
+package gotextfsm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var templateTestTemplate = `Value INTERFACE (\S+)
+Value List VLANS (\d+)
+
+Start
+  ^Interface ${INTERFACE}
+  ^\s+vlan ${VLANS}
+  ^$$ -> Record
+`
+
+func templateTestFS() fstest.MapFS {
+	return fstest.MapFS{
+		// Template with an input of the same name.
+		"intf.textfsm": {Data: []byte(templateTestTemplate)},
+		"intf.raw":     {Data: []byte("Interface eth0\n  vlan 10\n\nInterface eth1\n")},
+		"intf.yml": {Data: []byte(`- INTERFACE: "eth0"
+  VLANS:
+    - "10"
+- INTERFACE: "eth1"
+  VLANS: []
+`)},
+		// Inputs in the directory named after the template.
+		"dir/intf.textfsm":    {Data: []byte(templateTestTemplate)},
+		"dir/intf/pass.raw":   {Data: []byte("Interface eth0\n")},
+		"dir/intf/pass.json":  {Data: []byte(`[{"INTERFACE": "eth0", "VLANS": []}]`)},
+		"dir/intf/fail.raw":   {Data: []byte("Interface eth0\n  vlan 10\n  vlan 20\n\nInterface eth2\n\nInterface eth3\n")},
+		"dir/intf/fail.yaml":  {Data: []byte("- INTERFACE: eth0\n  VLANS: [\"10\"]\n  SPEED: \"1G\"\n- INTERFACE: eth1\n  VLANS: []\n")},
+		"dir/intf/noexp.raw":  {Data: []byte("Interface eth0\n")},
+		"dir/intf/badexp.raw": {Data: []byte("Interface eth0\n")},
+		"dir/intf/badexp.yml": {Data: []byte("INTERFACE: eth0\n")},
+		// ntc-templates layout with lower case keys.
+		"ntc/templates/cisco_ios_show_intf.textfsm":             {Data: []byte(templateTestTemplate)},
+		"ntc/tests/cisco_ios/show_intf/cisco_ios_show_intf.raw": {Data: []byte("Interface Gi0/1\n  vlan 1\n")},
+		"ntc/tests/cisco_ios/show_intf/cisco_ios_show_intf.yml": {Data: []byte("---\nparsed_sample:\n  - interface: \"Gi0/1\"\n    vlans:\n      - \"1\"\n")},
+		// Input without template. Invalid template.
+		"orphan/input.raw": {Data: []byte("")},
+		"broken.textfsm":   {Data: []byte("Value X (.*)\n\nStart\n  ^${Y}(\n")},
+		"broken.raw":       {Data: []byte("")},
+		"broken.yml":       {Data: []byte("[]")},
+	}
+}
+
+func TestDiscoverTemplateTests(t *testing.T) {
+	cases, err := DiscoverTemplateTests(templateTestFS())
+	if err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	expected := []TemplateTestCase{
+		{Template: "broken.textfsm", Input: "broken.raw", Expected: "broken.yml"},
+		{Template: "dir/intf.textfsm", Input: "dir/intf/badexp.raw", Expected: "dir/intf/badexp.yml"},
+		{Template: "dir/intf.textfsm", Input: "dir/intf/fail.raw", Expected: "dir/intf/fail.yaml"},
+		{Template: "dir/intf.textfsm", Input: "dir/intf/noexp.raw", Expected: ""},
+		{Template: "dir/intf.textfsm", Input: "dir/intf/pass.raw", Expected: "dir/intf/pass.json"},
+		{Template: "intf.textfsm", Input: "intf.raw", Expected: "intf.yml"},
+		{Template: "ntc/templates/cisco_ios_show_intf.textfsm", Input: "ntc/tests/cisco_ios/show_intf/cisco_ios_show_intf.raw", Expected: "ntc/tests/cisco_ios/show_intf/cisco_ios_show_intf.yml"},
+		{Template: "", Input: "orphan/input.raw", Expected: ""},
+	}
+	if len(cases) != len(expected) {
+		t.Fatalf("Expected %d test cases. Got %d: %v", len(expected), len(cases), cases)
+	}
+	for i, tc := range expected {
+		if cases[i] != tc {
+			t.Errorf("Test case %d dont match (%v, %v)", i, tc, cases[i])
+		}
+	}
+}
+
+func TestRunTemplateTests(t *testing.T) {
+	results, err := RunTemplateTests(templateTestFS(), TemplateTestOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	passed := map[string]bool{}
+	for _, result := range results {
+		passed[result.Case.Input] = result.Passed()
+	}
+	for input, expected := range map[string]bool{
+		"intf.raw":            true,
+		"dir/intf/pass.raw":   true,
+		"dir/intf/fail.raw":   false,
+		"dir/intf/noexp.raw":  false,
+		"dir/intf/badexp.raw": false,
+		"orphan/input.raw":    false,
+		"broken.raw":          false,
+		// Keys are not lower case without the option.
+		"ntc/tests/cisco_ios/show_intf/cisco_ios_show_intf.raw": false,
+	} {
+		if passed[input] != expected {
+			t.Errorf("'%s' failed. Expected passed to be %v", input, expected)
+		}
+	}
+	var sb strings.Builder
+	if WriteTemplateTestReport(&sb, results, true) {
+		t.Errorf("Expected report to fail")
+	}
+	report := sb.String()
+	for _, line := range []string{
+		"PASS intf.raw (intf.textfsm)",
+		"FAIL dir/intf/fail.raw (dir/intf.textfsm)",
+		`    Record 0: field 'SPEED' missing. Expected "1G"`,
+		`    Record 0: field 'VLANS' changed. Expected ["10"]. Got ["10","20"]`,
+		`    Record 1: field 'INTERFACE' changed. Expected "eth1". Got "eth2"`,
+		`    Record 2: extra {"INTERFACE":"eth3","VLANS":[]}`,
+		"    No expected records found for 'dir/intf/noexp.raw'",
+		"    dir/intf/badexp.yml: Expected a list of records",
+		"    No template found for 'orphan/input.raw'",
+		"2 passed, 6 failed",
+	} {
+		if !strings.Contains(report, line+"\n") {
+			t.Errorf("Report does not contain '%s'. Got\n%s", line, report)
+		}
+	}
+	results, _ = RunTemplateTests(templateTestFS(), TemplateTestOptions{LowercaseKeys: true})
+	for _, result := range results {
+		if strings.HasPrefix(result.Case.Input, "ntc/") && !result.Passed() {
+			t.Errorf("'%s' failed. Expected to pass with lower case keys. %v %v", result.Case.Input, result.Err, result.Diffs)
+		}
+	}
+	diffs := diffRecords([]map[string]interface{}{{"a": "1"}, {"a": "2"}}, []map[string]interface{}{{"a": "1", "b": []string{"x"}}})
+	expected := []string{`Record 0: field 'b' extra. Got ["x"]`, `Record 1: missing {"a":"2"}`}
+	if !stringListEquals(diffs, expected) {
+		t.Errorf("Diffs dont match. Got %v", diffs)
+	}
+}
+
+func TestUpdateTemplateTests(t *testing.T) {
+	dir := t.TempDir()
+	for name, file := range templateTestFS() {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, file.Data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Expected records in ntc-templates layout, with the 'parsed_sample' key, in JSON.
+	ntc := filepath.Join(dir, "ntc", "tests", "cisco_ios", "show_intf")
+	os.Remove(filepath.Join(ntc, "cisco_ios_show_intf.yml"))
+	os.WriteFile(filepath.Join(ntc, "cisco_ios_show_intf.json"), []byte(`{"parsed_sample": []}`), 0644)
+
+	results, err := UpdateTemplateTests(dir, TemplateTestOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	var sb strings.Builder
+	WriteTemplateTestReport(&sb, results, false)
+	if !strings.Contains(sb.String(), "2 passed, 2 failed, 4 updated\n") {
+		t.Errorf("Report dont match. Got\n%s", sb.String())
+	}
+	expected := map[string]string{
+		"dir/intf/fail.yaml": `- INTERFACE: "eth0"
+  VLANS:
+    - "10"
+    - "20"
+- INTERFACE: "eth2"
+  VLANS: []
+- INTERFACE: "eth3"
+  VLANS: []
+`,
+		// New file for the test without expected records.
+		"dir/intf/noexp.yml": `- INTERFACE: "eth0"
+  VLANS: []
+`,
+		"ntc/tests/cisco_ios/show_intf/cisco_ios_show_intf.json": `{
+  "parsed_sample": [
+    {
+      "INTERFACE": "Gi0/1",
+      "VLANS": [
+        "1"
+      ]
+    }
+  ]
+}
+`,
+		// Passed tests are not rewritten.
+		"dir/intf/pass.json": `[{"INTERFACE": "eth0", "VLANS": []}]`,
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("'%s' failed. Expected no error. But found error '%s'", name, err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("'%s' failed. Content dont match. Got\n%s", name, string(data))
+		}
+	}
+	// All the tests that could be updated pass now. Updating again changes nothing.
+	results, err = UpdateTemplateTests(dir, TemplateTestOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	for _, result := range results {
+		if result.Updated {
+			t.Errorf("'%s' failed. Expected no update", result.Case.Input)
+		}
+	}
+
+	// Lower case keys are written in the order of the Values.
+	os.WriteFile(filepath.Join(ntc, "cisco_ios_show_intf.json"), []byte(`[]`), 0644)
+	results, _ = UpdateTemplateTests(filepath.Join(dir, "ntc"), TemplateTestOptions{LowercaseKeys: true})
+	data, _ := os.ReadFile(filepath.Join(ntc, "cisco_ios_show_intf.json"))
+	if len(results) != 1 || !results[0].Updated || string(data) != "[\n  {\n    \"interface\": \"Gi0/1\",\n    \"vlans\": [\n      \"1\"\n    ]\n  }\n]\n" {
+		t.Errorf("Lower case keys dont match. Got\n%s", string(data))
+	}
+
+	var buf bytes.Buffer
+	if err := writeExpectedYAML(&buf, TextFSM{}, []map[string]interface{}{}, true); err != nil || buf.String() != "---\nparsed_sample: []\n" {
+		t.Errorf("Empty 'parsed_sample' dont match. Got '%s'", buf.String())
+	}
+	records := []map[string]interface{}{{"a": "1"}}
+	buf.Reset()
+	writeExpectedYAML(&buf, TextFSM{}, records, true)
+	if buf.String() != "---\nparsed_sample:\n  - a: \"1\"\n" {
+		t.Errorf("'parsed_sample' dont match. Got '%s'", buf.String())
+	}
+	if doc, err := parseYAML(buf.String()); err != nil || !hasParsedSample("x.yml", buf.Bytes()) {
+		t.Errorf("Written YAML should be readable. Got %v, %v", doc, err)
+	}
+}
@@ -14,6 +14,31 @@ type TextFSM struct {
 	Values             map[string]TextFSMValue
 	States             map[string]TextFSMState
 	line_num           int
+	value_names        []string
+	state_names        []string
+	levels             map[string]string // Paths of the levels by their names. ex. {'Secondary': 'Addresses.Secondary'}
+	level_paths        []string          // Paths of the levels. A level comes after its parent.
+	table_names        []string          // Names of the tables in the order they were declared.
+}
+
+// ValueNames returns the names of the Values in the order they were declared in the template.
+// The returned slice is a copy and can be modified by the caller.
+func (t *TextFSM) ValueNames() []string {
+	return append([]string{}, t.value_names...)
+}
+
+// StateNames returns the names of the states in the order they were declared in the template.
+// The 'End' state is not included as it is removed during validation.
+// The returned slice is a copy and can be modified by the caller.
+func (t *TextFSM) StateNames() []string {
+	return append([]string{}, t.state_names...)
+}
+
+// GetState returns the state with the given name and true if it exists.
+// Returns an empty state and false otherwise.
+func (t *TextFSM) GetState(name string) (TextFSMState, bool) {
+	state, exists := t.States[name]
+	return state, exists
 }
 
 // Parses the string passed, into a TextFSM structure.
@@ -29,16 +54,26 @@ func (t *TextFSM) ParseReader(reader *strings.Reader) error {
 	return t.ParseScanner(bufio.NewScanner(reader))
 }
 
-func (t *TextFSM) ParseScanner(scanner *bufio.Scanner) error {
+func (t *TextFSM) ParseScanner(scanner *bufio.Scanner) (err error) {
+	defer t.recoverInternalError("ParseTemplate", &err)
 	t.COMMENT_RE = regexp.MustCompile(`^\s*#`)
 	t.STATE_RE = regexp.MustCompile(`^(\w+)$`)
 	t.MAX_STATE_NAME_LEN = 48
 	t.line_num = 0
-	err := t.parseFSMVariables(scanner)
+	err = t.parseFSMVariables(scanner)
+	if err != nil {
+		return err
+	}
+	err = t.parseLevels()
+	if err != nil {
+		return err
+	}
+	err = t.parseTables()
 	if err != nil {
 		return err
 	}
 	t.States = make(map[string]TextFSMState)
+	t.state_names = make([]string, 0)
 	for {
 		done, err := t.parseFSMStates(scanner)
 		if err != nil {
@@ -64,6 +99,7 @@ func (t *TextFSM) ParseScanner(scanner *bufio.Scanner) error {
 //       returns error if there is any error while parsing. nil otherwise.
 func (t *TextFSM) parseFSMVariables(scanner *bufio.Scanner) error {
 	t.Values = make(map[string]TextFSMValue)
+	t.value_names = make([]string, 0)
 	t.line_num = 0
 	for {
 		t.line_num++
@@ -93,6 +129,9 @@ func (t *TextFSM) parseFSMVariables(scanner *bufio.Scanner) error {
 			if err != nil {
 				return err
 			}
+			if _, exists := t.Values[value.Name]; !exists {
+				t.value_names = append(t.value_names, value.Name)
+			}
 			t.Values[value.Name] = value
 		} else if len(t.Values) == 0 {
 			return fmt.Errorf("No Value definitions found.")
@@ -138,16 +177,23 @@ func (t *TextFSM) parseFSMStates(scanner *bufio.Scanner) (done bool, err error)
 		if len(line) > t.MAX_STATE_NAME_LEN {
 			return false, fmt.Errorf("%d Line: state name too long. Should be < %d chars", t.line_num, len(line))
 		}
-		if FindIndex(LINE_OPERATORS, line) >= 0 || FindIndex(RECORD_OPERATORS, line) >= 0 {
+		if FindIndex(LINE_OPERATORS, line) >= 0 || FindIndex(RECORD_OPERATORS, line) >= 0 || FindIndex(STATE_OPERATORS, line) >= 0 {
 			return false, fmt.Errorf("%d Line: state '%s' can not be a keyword", t.line_num, line)
 		}
 		if _, exists := t.States[line]; exists {
 			return false, fmt.Errorf("%d Line: Duplicate state name '%s'", t.line_num, line)
 		}
+		if _, exists := t.levels[line]; exists {
+			return false, fmt.Errorf("%d Line: state '%s' has the same name as a level", t.line_num, line)
+		}
+		if FindIndex(t.table_names, line) >= 0 {
+			return false, fmt.Errorf("%d Line: state '%s' has the same name as a table", t.line_num, line)
+		}
 		state := TextFSMState{name: line, fsm: t}
 		done, err = state.parseFSMRules(scanner)
 		if err == nil {
 			state.fsm.States[line] = state
+			state.fsm.state_names = append(state.fsm.state_names, line)
 		}
 		return done, err
 	}
@@ -176,17 +222,10 @@ func (t *TextFSMState) parseFSMRules(scanner *bufio.Scanner) (done bool, err err
 		if t.fsm.COMMENT_RE.MatchString(line) {
 			continue
 		}
-		valid := false
-		for _, prefix := range []string{" ^", "  ^", "\t^"} {
-			if strings.HasPrefix(line, prefix) {
-				valid = true
-				break
-			}
-		}
-		if !valid {
+		if !isRule(line) {
 			return false, fmt.Errorf("%d Line: Missing white space or carat ('^') before rule.", t.fsm.line_num)
 		}
-		rule := TextFSMRule{}
+		rule := TextFSMRule{levels: t.fsm.levels, tables: t.fsm.table_names}
 		varmap := make(map[string]interface{})
 		for key, val := range t.fsm.Values {
 			varmap[key] = val.Template
@@ -204,9 +243,12 @@ func (t *TextFSMState) parseFSMRules(scanner *bufio.Scanner) (done bool, err err
 // not be a reserved name.
 // There must be a 'Start' state and if 'EOF' or 'End' states are specified,
 // they must be empty.
+// Called states must not be able to call themselves again. (See checkCalls)
 // Returns:
 //   error if the FSM is invalid
 func (t *TextFSM) validateFSM() error {
+	// Errors of a rule set the line number to the line of the rule. See ParseFS.
+	t.line_num = 0
 	// Must have 'Start' state.
 	if _, exists := t.States["Start"]; !exists {
 		return fmt.Errorf("Missing state 'Start'.")
@@ -218,6 +260,9 @@ func (t *TextFSM) validateFSM() error {
 		} else {
 			// Remove 'End' state.
 			delete(t.States, "End")
+			if idx := FindIndex(t.state_names, "End"); idx >= 0 {
+				t.state_names = append(t.state_names[:idx], t.state_names[idx+1:]...)
+			}
 		}
 	}
 	if state, exists := t.States["EOF"]; exists {
@@ -231,13 +276,18 @@ func (t *TextFSM) validateFSM() error {
 			if rule.LineOp == "Error" {
 				continue
 			}
-			if rule.NewState == "" || rule.NewState == "End" || rule.NewState == "EOF" {
+			if rule.Call && (rule.NewState == "End" || rule.NewState == "EOF") {
+				t.line_num = rule.LineNum
+				return fmt.Errorf("State '%s' can not be called, referenced in state '%s'", rule.NewState, name)
+			}
+			if rule.NewState == "" || rule.NewState == "End" || rule.NewState == "EOF" || rule.NewState == "Return" {
 				continue
 			}
 			if _, exists := t.States[rule.NewState]; !exists {
+				t.line_num = rule.LineNum
 				return fmt.Errorf("State '%s' not found, referenced in state '%s'", rule.NewState, name)
 			}
 		}
 	}
-	return nil
+	return t.checkCalls()
 }
@@ -260,19 +260,30 @@ Start
 	{
 		name: "Multiple variables and substitution",
 		input: `Value Beer (.)
+Value Wine (\w)
 
 Start
   ^.${Beer}${Wine}.
   ^Hello $Beer
   ^Last-[Cc]ha$$nge
 `,
-		values: map[string]string{"Beer": "Value Beer (.)"},
+		values: map[string]string{"Beer": "Value Beer (.)", "Wine": "Value Wine (\\w)"},
 		states: map[string][]string{"Start": []string{
 			" ^.${Beer}${Wine}.",
 			" ^Hello $Beer",
 			" ^Last-[Cc]ha$$nge",
 		}},
 	},
+	{
+		name:  "Unknown variable in rule",
+		input: "Value Beer (.)\n\nStart\n  ^.${Beer}${Wine}.\n",
+		err:   regexp.MustCompile(`Unknown variable 'Wine': line 1, col 10`),
+	},
+	{
+		name:  "Invalid placeholder in rule",
+		input: "Value Beer (.)\n\nStart\n  ^Hello $Beer$\n",
+		err:   regexp.MustCompile(`Invalid placeholder in string: line 1, col 14`),
+	},
 	{
 		name:  "State name too long (>32 char)",
 		input: "Value Beer (.)\n\nrnametoolong_nametoolong_nametoolong_nametoolong_nametoolo\n  ^.\n  ^Hello World\n",
@@ -490,3 +501,68 @@ EOF
 		states: map[string][]string{"Start": []string{` ^\s+in\s+use\s+settings\s+=\{${INBOUND_SETTINGS_IN_USE},\s+\}\s*`}, "EOF": []string{}},
 	},
 }
+
+func TestFSMIntrospection(t *testing.T) {
+	template := `Value Required name (\S+)
+Value age (\d+)
+Value List pets (\w+)
+
+Start
+  ^Name: ${name}\s+Age: ${age} -> Person
+  ^End -> End
+
+Person
+  ^Pet: ${pets}
+  ^Pet: ${pets} and ${pets} -> Continue
+  ^$$ -> Record Start
+
+End
+`
+	fsm := TextFSM{}
+	if err := fsm.ParseString(template); err != nil {
+		t.Fatalf("Expected no error. But found error '%s'", err)
+	}
+	if names := fsm.ValueNames(); !stringListEquals(names, []string{"name", "age", "pets"}) {
+		t.Errorf("Value names dont match. Found %v", names)
+	}
+	if names := fsm.StateNames(); !stringListEquals(names, []string{"Start", "Person"}) {
+		t.Errorf("State names dont match. Found %v", names)
+	}
+	if _, exists := fsm.GetState("End"); exists {
+		t.Errorf("'End' state should not be present")
+	}
+	state, exists := fsm.GetState("Person")
+	if !exists {
+		t.Fatalf("State 'Person' not found")
+	}
+	if state.Name() != "Person" {
+		t.Errorf("State name dont match. Found '%s'", state.Name())
+	}
+	rules := state.Rules()
+	if len(rules) != 3 {
+		t.Fatalf("Expected 3 rules. Found %d", len(rules))
+	}
+	if rules[1].Source() != "  ^Pet: ${pets} and ${pets} -> Continue" {
+		t.Errorf("Rule source dont match. Found '%s'", rules[1].Source())
+	}
+	if rules[1].LineNum != 11 || rules[1].LineOp != "Continue" {
+		t.Errorf("Rule actions dont match. Found '%s' at line %d", rules[1].LineOp, rules[1].LineNum)
+	}
+	if values := rules[1].Values(); !stringListEquals(values, []string{"pets"}) {
+		t.Errorf("Rule values dont match. Found %v", values)
+	}
+	if values := rules[2].Values(); len(values) != 0 {
+		t.Errorf("Expected no values. Found %v", values)
+	}
+	start, _ := fsm.GetState("Start")
+	if values := start.Rules()[0].Values(); !stringListEquals(values, []string{"name", "age"}) {
+		t.Errorf("Rule values dont match. Found %v", values)
+	}
+	// Modifying the returned copies must not affect the template.
+	rules[0].Regex = "modified"
+	rules[1].Values()[0] = "modified"
+	fsm.ValueNames()[0] = "modified"
+	if state.Rules()[0].Regex == "modified" || state.Rules()[1].Values()[0] == "modified" || fsm.ValueNames()[0] == "modified" {
+		t.Errorf("Returned values should be copies")
+	}
+}
@@ -0,0 +1,388 @@
+package gotextfsm
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TABLE_MODE int
+
+const (
+	// FORMATTED_TABLE renders aligned and wrapped columns like texttable.FormattedTable() of Python.
+	FORMATTED_TABLE TABLE_MODE = iota
+	// RAW_TABLE renders each row as its values separated by ', ' like str(texttable) of Python.
+	RAW_TABLE
+)
+
+// TableOptions controls RenderTable.
+//     Mode: FORMATTED_TABLE (default) or RAW_TABLE.
+//     Width: Width of the formatted table. Default is 80.
+//     ForceDisplay: Render the formatted table even if it does not fit in Width.
+//     NoMLDelimiter: Do not separate multi-line rows with a line of '-'.
+//     HideHeader: Do not render the header of the formatted table.
+// The defaults are same as the defaults of Python's FormattedTable().
+type TableOptions struct {
+	Mode          TABLE_MODE
+	Width         int
+	ForceDisplay  bool
+	NoMLDelimiter bool
+	HideHeader    bool
+}
+
+// RenderTable renders the records as a table the way Python's texttable module does.
+// So that the output can be compared with that of Python's TextFSM.
+// The columns are the Values in the order of their declaration in the template.
+// Only the Values of the top level records are rendered. (See 'Level=' option)
+//     Args:
+//       w: (io.Writer), Destination of the table.
+//       fsm: (TextFSM), Template used to parse the records.
+//       records: ([]map[string]interface{}), Records to render. Usually ParserOutput.Dict
+//       options: (TableOptions), See TableOptions.
+//     Returns:
+//       error if the table does not fit in the width or if there is any error while writing.
+func RenderTable(w io.Writer, fsm TextFSM, records []map[string]interface{}, options TableOptions) (err error) {
+	defer recoverInternalError("RenderTable", &err)
+	columns := fsm.levelValueNames("")
+	if options.Mode == RAW_TABLE {
+		var sb strings.Builder
+		sb.WriteString(strings.Join(columns, ", ") + "\n")
+		for _, record := range records {
+			cells := make([]string, 0, len(columns))
+			for _, name := range columns {
+				cells = append(cells, pyStr(record[name], fsm.Values[name].group_names))
+			}
+			sb.WriteString(strings.Join(cells, ", ") + "\n")
+		}
+		_, err := io.WriteString(w, sb.String())
+		return err
+	}
+	width := options.Width
+	if width <= 0 {
+		width = 80
+	}
+	// Text of each cell. First row is the header.
+	rows := make([][]string, 0, len(records)+1)
+	rows = append(rows, columns)
+	for _, record := range records {
+		row := make([]string, 0, len(columns))
+		for _, name := range columns {
+			row = append(row, tableCell(record[name], fsm.Values[name].group_names))
+		}
+		rows = append(rows, row)
+	}
+	// largest is the biggest data entry in a column.
+	// smallest is the largest unbroken word in a column (i.e. with line wrap).
+	largest := make([]int, len(columns))
+	smallest := make([]int, len(columns))
+	for _, row := range rows {
+		for i, cell := range row {
+			if l := utf8.RuneCountInString(cell); l > largest[i] {
+				largest[i] = l
+			}
+			if l := smallestColSize(cell); l > smallest[i] {
+				smallest[i] = l
+			}
+		}
+	}
+	min_total_width := 0
+	multi_word := make([]int, 0)
+	for i := range columns {
+		// Each column is bracketed by a space on both sides.
+		largest[i] += 2
+		smallest[i] += 2
+		min_total_width += smallest[i]
+		// Column contains data that could be split over multiple lines.
+		if largest[i] != smallest[i] {
+			multi_word = append(multi_word, i)
+		}
+	}
+	if min_total_width > width && !options.ForceDisplay {
+		return fmt.Errorf("Width too narrow to display table.")
+	}
+	if len(multi_word) > 0 {
+		// Space left over for the wrapped columns (spare_width) and
+		// the space needed if they were not wrapped (desired_width).
+		desired_width := 0
+		spare_width := width - min_total_width
+		for _, i := range multi_word {
+			spare_width += smallest[i]
+			desired_width += largest[i]
+		}
+		// Scale up the space of each wrapped column in proportion to its size. Columns that reach their
+		// desired width (or stay at their minimum) are removed from the list, until the list does not change.
+		// Note: Like Python, the element following a removed element is skipped in that iteration.
+		done := false
+		for !done {
+			done = true
+			for idx := 0; idx < len(multi_word); idx++ {
+				i := multi_word[idx]
+				scaled := pyRound(float64(largest[i]) / float64(desired_width) * float64(spare_width))
+				if largest[i] <= scaled {
+					smallest[i] = largest[i]
+					multi_word = append(multi_word[:idx], multi_word[idx+1:]...)
+					spare_width -= smallest[i]
+					desired_width -= largest[i]
+					done = false
+				} else if smallest[i] >= scaled {
+					multi_word = append(multi_word[:idx], multi_word[idx+1:]...)
+					spare_width -= smallest[i]
+					desired_width -= largest[i]
+					done = false
+				}
+			}
+		}
+		for _, i := range multi_word {
+			smallest[i] = pyRound(float64(largest[i]) / float64(desired_width) * float64(spare_width))
+		}
+	}
+	total_width := 0
+	for i := range columns {
+		total_width += smallest[i]
+	}
+	var sb strings.Builder
+	prev_multi_line := false
+	for r, row := range rows {
+		lines := make([][]string, len(columns))
+		row_count := 0
+		for i, cell := range row {
+			justified, err := textJustify(cell, smallest[i])
+			if err != nil {
+				return err
+			}
+			lines[i] = justified
+			if len(justified) > row_count {
+				row_count = len(justified)
+			}
+		}
+		if r == 0 {
+			if options.HideHeader {
+				continue
+			}
+		} else {
+			if row_count > 1 {
+				prev_multi_line = true
+			}
+			// If current or prior line was multi-line then include delimiter.
+			// Except for the first row, which follows the header line.
+			if r > 1 && prev_multi_line && !options.NoMLDelimiter {
+				sb.WriteString(strings.Repeat("-", total_width) + "\n")
+				if row_count == 1 {
+					prev_multi_line = false
+				}
+			}
+		}
+		for l := 0; l < row_count; l++ {
+			for i := range columns {
+				if l < len(lines[i]) {
+					sb.WriteString(lines[i][l])
+				} else {
+					sb.WriteString(strings.Repeat(" ", smallest[i]))
+				}
+			}
+			sb.WriteString("\n")
+		}
+		if r == 0 {
+			sb.WriteString(strings.Repeat("=", total_width) + "\n")
+		}
+	}
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// pyRound rounds half to even like round() of Python 3.
+func pyRound(val float64) int {
+	return int(math.RoundToEven(val))
+}
+
+// tableCell returns the text of a value in a formatted table. List values are joined by ', '.
+func tableCell(val interface{}, keys []string) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ", ")
+	case []map[string]string:
+		elems := make([]string, 0, len(v))
+		for _, elem := range v {
+			elems = append(elems, pyStr(elem, keys))
+		}
+		return strings.Join(elems, ", ")
+	}
+	return pyStr(val, keys)
+}
+
+func smallestColSize(text string) int {
+	size := 0
+	for _, word := range strings.Fields(text) {
+		if l := utf8.RuneCountInString(word); l > size {
+			size = l
+		}
+	}
+	return size
+}
+
+// textJustify wraps the text to lines of the column size. Each line is padded with a space on the left
+// and spaces on the right to fill the column.
+func textJustify(text string, col_size int) ([]string, error) {
+	result := make([]string, 0)
+	if strings.Contains(text, "\n") {
+		for _, paragraph := range strings.Split(text, "\n") {
+			lines, err := textJustify(paragraph, col_size)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, lines...)
+		}
+		return result, nil
+	}
+	if col_size-2 <= 0 {
+		return nil, fmt.Errorf("Field too small (minimum width: 3)")
+	}
+	lines := textWrap(text, col_size-2)
+	if len(lines) == 0 {
+		return []string{strings.Repeat(" ", col_size)}, nil
+	}
+	for _, line := range lines {
+		l := utf8.RuneCountInString(line)
+		// +2 for white space on either side.
+		if l+2 > col_size {
+			return nil, fmt.Errorf("String contains words that do not fit in column.")
+		}
+		result = append(result, " "+line+strings.Repeat(" ", col_size-1-l))
+	}
+	return result, nil
+}
+
+// textWrap wraps the text to lines of at most width characters the way textwrap.TextWrapper of Python does
+// with break_long_words=False. Words longer than the width are put on their own line.
+// Whitespace at the start of the lines (except the first) and at the end of the lines is dropped.
+func textWrap(text string, width int) []string {
+	chunks := splitChunks(text)
+	lines := make([]string, 0)
+	for len(chunks) > 0 {
+		cur_line := make([]string, 0)
+		cur_len := 0
+		if strings.TrimSpace(chunks[0]) == "" && len(lines) > 0 {
+			chunks = chunks[1:]
+		}
+		for len(chunks) > 0 {
+			l := utf8.RuneCountInString(chunks[0])
+			if cur_len+l > width {
+				break
+			}
+			cur_line = append(cur_line, chunks[0])
+			cur_len += l
+			chunks = chunks[1:]
+		}
+		if len(chunks) > 0 && utf8.RuneCountInString(chunks[0]) > width && len(cur_line) == 0 {
+			cur_line = append(cur_line, chunks[0])
+			chunks = chunks[1:]
+		}
+		if len(cur_line) > 0 && strings.TrimSpace(cur_line[len(cur_line)-1]) == "" {
+			cur_line = cur_line[:len(cur_line)-1]
+		}
+		if len(cur_line) > 0 {
+			lines = append(lines, strings.Join(cur_line, ""))
+		}
+	}
+	return lines
+}
+
+// splitChunks splits the text into runs of whitespace and words. Whitespace characters are replaced by spaces.
+// Hyphenated words (ex. 'full-duplex') are split after the hyphen.
+func splitChunks(text string) []string {
+	runes := []rune(text)
+	chunks := make([]string, 0)
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		if i == len(runes) || (i > start && unicode.IsSpace(runes[i]) != unicode.IsSpace(runes[start])) {
+			if i > start {
+				if unicode.IsSpace(runes[start]) {
+					chunks = append(chunks, strings.Repeat(" ", i-start))
+				} else {
+					chunks = append(chunks, splitHyphens(runes[start:i])...)
+				}
+			}
+			start = i
+		}
+	}
+	return chunks
+}
+
+func splitHyphens(word []rune) []string {
+	chunks := make([]string, 0)
+	start := 0
+	for i := 2; i < len(word)-2; i++ {
+		if word[i] == '-' && unicode.IsLetter(word[i-1]) && unicode.IsLetter(word[i-2]) &&
+			unicode.IsLetter(word[i+1]) && unicode.IsLetter(word[i+2]) {
+			chunks = append(chunks, string(word[start:i+1]))
+			start = i + 1
+		}
+	}
+	return append(chunks, string(word[start:]))
+}
+
+// pyStr returns the text of the value as str() of Python would return it.
+// ex: ['a', 'b'] for a List and {'ip': '10.0.0.1'} for a value with nested match groups.
+func pyStr(val interface{}, keys []string) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		elems := make([]string, 0, len(v))
+		for _, elem := range v {
+			elems = append(elems, pyRepr(elem))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case map[string]string:
+		pairs := make([]string, 0, len(v))
+		for _, key := range mapKeys(v, keys) {
+			pairs = append(pairs, pyRepr(key)+": "+pyRepr(v[key]))
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	case []map[string]string:
+		elems := make([]string, 0, len(v))
+		for _, elem := range v {
+			elems = append(elems, pyStr(elem, keys))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// pyRepr returns the string quoted as repr() of Python would.
+func pyRepr(str string) string {
+	quote := '\''
+	if strings.ContainsRune(str, '\'') && !strings.ContainsRune(str, '"') {
+		quote = '"'
+	}
+	var sb strings.Builder
+	sb.WriteRune(quote)
+	for _, r := range str {
+		switch {
+		case r == quote || r == '\\':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			sb.WriteString(fmt.Sprintf(`\x%02x`, r))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteRune(quote)
+	return sb.String()
+}
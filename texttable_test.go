@@ -0,0 +1,149 @@
+package gotextfsm
+
+import (
+	"strings"
+	"testing"
+)
+
+type tableTestCase struct {
+	name     string
+	template string
+	records  []map[string]interface{}
+	options  TableOptions
+	output   string
+	err      bool
+}
+
+func TestRenderTable(t *testing.T) {
+	for _, tc := range tableTestCases {
+		fsm := TextFSM{}
+		if err := fsm.ParseString(tc.template); err != nil {
+			t.Errorf("'%s' failed. TextFSM should be valid. But got error '%s'", tc.name, err.Error())
+			continue
+		}
+		var sb strings.Builder
+		err := RenderTable(&sb, fsm, tc.records, tc.options)
+		if tc.err {
+			if err == nil {
+				t.Errorf("'%s' failed. Expected error, but none found", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("'%s' failed. Expected no error. But found error %s", tc.name, err)
+			continue
+		}
+		if sb.String() != tc.output {
+			t.Errorf("'%s' failed. Outputs dont match. Expected\n%s\nGot\n%s", tc.name, tc.output, sb.String())
+		}
+	}
+	t.Logf("Executed %d test cases", len(tableTestCases))
+}
+
+var tableTemplate = "Value device (\\S+)\nValue description (.*)\nValue List vlans (\\d+)\n\nStart\n  ^${device} ${description}\n"
+
+var tableRecords = []map[string]interface{}{
+	{"device": "sw1", "description": "Uplink to the core switch in building one, full-duplex", "vlans": []string{"10", "20", "30"}},
+	{"device": "sw2", "description": "short", "vlans": []string{}},
+	{"device": "sw3", "description": "another rather long description that must wrap around the column", "vlans": []string{"1"}},
+}
+
+// Expected outputs of formatted tables are generated with Python's texttable.
+var tableTestCases = []tableTestCase{
+	{
+		name:     "Default width",
+		template: tableTemplate,
+		records:  tableRecords,
+		output: " device  description                                                  vlans     \n" +
+			"================================================================================\n" +
+			" sw1     Uplink to the core switch in building one, full-duplex       10, 20,   \n" +
+			"                                                                      30        \n" +
+			"--------------------------------------------------------------------------------\n" +
+			" sw2     short                                                                  \n" +
+			"--------------------------------------------------------------------------------\n" +
+			" sw3     another rather long description that must wrap around the    1         \n" +
+			"         column                                                                 \n",
+	},
+	{
+		name:     "Narrow width",
+		template: tableTemplate,
+		records:  tableRecords,
+		options:  TableOptions{Width: 40},
+		output: " device  description              vlans \n" +
+			"========================================\n" +
+			" sw1     Uplink to the core       10,   \n" +
+			"         switch in building one,  20,   \n" +
+			"         full-duplex              30    \n" +
+			"----------------------------------------\n" +
+			" sw2     short                          \n" +
+			"----------------------------------------\n" +
+			" sw3     another rather long      1     \n" +
+			"         description that must          \n" +
+			"         wrap around the column         \n",
+	},
+	{
+		name:     "No multi-line delimiter and no header",
+		template: tableTemplate,
+		records:  tableRecords,
+		options:  TableOptions{Width: 40, NoMLDelimiter: true, HideHeader: true},
+		output: " sw1     Uplink to the core       10,   \n" +
+			"         switch in building one,  20,   \n" +
+			"         full-duplex              30    \n" +
+			" sw2     short                          \n" +
+			" sw3     another rather long      1     \n" +
+			"         description that must          \n" +
+			"         wrap around the column         \n",
+	},
+	{
+		name:     "Delimiter only around multi-line rows",
+		template: "Value a (.*)\nValue b (.*)\n\nStart\n  ^${a} ${b}\n",
+		records: []map[string]interface{}{
+			{"a": "x", "b": "y"},
+			{"a": "multi word value", "b": "z"},
+			{"a": "p", "b": "q"},
+		},
+		options: TableOptions{Width: 12},
+		output: " a        b \n" +
+			"============\n" +
+			" x        y \n" +
+			"------------\n" +
+			" multi    z \n" +
+			" word       \n" +
+			" value      \n" +
+			"------------\n" +
+			" p        q \n",
+	},
+	{
+		name:     "Too narrow",
+		template: "Value name (.*)\n\nStart\n  ^${name}\n",
+		records:  []map[string]interface{}{{"name": "averyveryverylongwordthatdoesnotfit"}},
+		options:  TableOptions{Width: 10},
+		err:      true,
+	},
+	{
+		name:     "Too narrow, forced",
+		template: "Value name (.*)\n\nStart\n  ^${name}\n",
+		records:  []map[string]interface{}{{"name": "averyveryverylongwordthatdoesnotfit"}},
+		options:  TableOptions{Width: 10, ForceDisplay: true},
+		output: " name" + strings.Repeat(" ", 32) + "\n" +
+			strings.Repeat("=", 37) + "\n" +
+			" averyveryverylongwordthatdoesnotfit \n",
+	},
+	{
+		name:     "No records",
+		template: "Value col_one (.*)\nValue col_two (.*)\n\nStart\n  ^${col_one}\n",
+		output:   " col_one  col_two \n==================\n",
+	},
+	{
+		name:     "Raw",
+		template: "Value device (\\S+)\nValue List vlans (\\d+)\nValue address ((?P<ip>[\\d.]+)/(?P<mask>\\d+))\nValue List peers ((?P<name>\\w+):(?P<asn>\\d+))\n\nStart\n  ^${device}\n",
+		records: []map[string]interface{}{
+			{"device": "sw1", "vlans": []string{"10", "it's"}, "address": map[string]string{"ip": "10.0.0.1", "mask": "24"}, "peers": []map[string]string{{"name": "a", "asn": "1"}}},
+			{"device": "", "vlans": []string{}, "address": map[string]string{}, "peers": []map[string]string{}},
+		},
+		options: TableOptions{Mode: RAW_TABLE},
+		output: "device, vlans, address, peers\n" +
+			"sw1, ['10', \"it's\"], {'ip': '10.0.0.1', 'mask': '24'}, [{'name': 'a', 'asn': '1'}]\n" +
+			", [], {}, []\n",
+	},
+}
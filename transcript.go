@@ -0,0 +1,123 @@
+package gotextfsm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Prompts of common network devices at the start of a line, followed by the command (if any).
+// ex. 'router1#show version', 'router1(config-if)# shutdown', 'user@host> show route', '<HUAWEI>display version'
+// The prompt (group 1) starts with a host name. Without a command, the prompt must be the whole line.
+var PROMPT_RE = regexp.MustCompile(`^(<[\w.\-]+>|[A-Za-z][\w.\-]*(?:@[\w.\-]+)?(?::[\w.\-/~]*)?(?:\([\w.\-/]+\))?[#>])(?:\s*\S|$)`)
+
+// TranscriptSection is the output of one command in the transcript of a CLI session.
+//     Line: Line number of the prompt in the transcript
+//     Prompt: Prompt. ex. 'router1#'
+//     Command: Command echoed after the prompt, without the surrounding spaces
+//     Output: Lines between the prompt and the end of the section (See SplitTranscript)
+type TranscriptSection struct {
+	Line    int
+	Prompt  string
+	Command string
+	Output  string
+}
+
+// SplitTranscript splits the transcript of a CLI session into the sections of the commands.
+// A line that matches the prompt regex (at the start of the line) with a command (the rest of the line) starts a section.
+// If the regex has a group, the first group is the prompt. So that the regex can require more than the prompt. See PROMPT_RE.
+// A line that matches without a command ends the section, if it has the host of the prompt of the section
+// (ex. 'router1(config)#' after 'router1#show version') or if it is the last line (ignoring blank lines) of the transcript.
+// Otherwise, it is a line of the output. As output lines can look like prompts. ex. 'word#'
+// Lines before the first prompt (ex. banner) and lines after the end of a section (up to the next prompt) are ignored.
+//     Args:
+//       transcript: Text of the session. Lines can be terminated by "\n" or "\r\n".
+//       prompt: Regex of the prompt. PROMPT_RE if nil.
+func SplitTranscript(transcript string, prompt *regexp.Regexp) []TranscriptSection {
+	if prompt == nil {
+		prompt = PROMPT_RE
+	}
+	sections := make([]TranscriptSection, 0)
+	var output strings.Builder
+	// A section is open until the next prompt. Its output is set when it is closed.
+	open := false
+	closeSection := func() {
+		if open {
+			sections[len(sections)-1].Output = output.String()
+		}
+		output.Reset()
+		open = false
+	}
+	lines := strings.SplitAfter(transcript, "\n")
+	last := len(lines) - 1
+	for last > 0 && strings.TrimSpace(lines[last]) == "" {
+		last--
+	}
+	for i, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+		loc := prompt.FindStringSubmatchIndex(text)
+		if loc != nil && loc[0] == 0 {
+			end := loc[1]
+			if len(loc) > 2 && loc[3] >= 0 {
+				end = loc[3]
+			}
+			command := strings.TrimSpace(text[end:])
+			if command != "" {
+				closeSection()
+				sections = append(sections, TranscriptSection{Line: i + 1, Prompt: text[:end], Command: command})
+				open = true
+				continue
+			}
+			if open && (promptHost(text[:end]) == promptHost(sections[len(sections)-1].Prompt) || i == last) {
+				closeSection()
+				continue
+			}
+		}
+		if open {
+			output.WriteString(text)
+			if i < len(lines)-1 {
+				output.WriteString("\n")
+			}
+		}
+	}
+	closeSection()
+	return sections
+}
+
+// promptHost returns the host name of the prompt. ex. 'router1' of 'router1(config)#', 'user@host' of 'user@host:~>'
+func promptHost(prompt string) string {
+	host := strings.TrimPrefix(prompt, "<")
+	if idx := strings.IndexAny(host, "(:#>"); idx >= 0 {
+		host = host[:idx]
+	}
+	return host
+}
+
+// TranscriptResult is the result of parsing the output of one command of a transcript.
+// Dict is nil if there is an error.
+type TranscriptResult struct {
+	Section TranscriptSection
+	Dict    []map[string]interface{}
+	Err     error
+}
+
+// ParseTranscript splits the transcript (See SplitTranscript) and parses the output of each command with the templates
+// found in the index for the command and the attributes (ex. {"Platform": "cisco_ios"}). See ParseCmd.
+// Sections without a command (ex. prompt at the end of the transcript) are skipped.
+//     Returns:
+//       Results of the commands, in the order of the transcript.
+func (c *CliTable) ParseTranscript(transcript string, prompt *regexp.Regexp, attributes map[string]string) []TranscriptResult {
+	results := make([]TranscriptResult, 0)
+	for _, section := range SplitTranscript(transcript, prompt) {
+		if section.Command == "" {
+			continue
+		}
+		attrs := make(map[string]string, len(attributes)+1)
+		for name, value := range attributes {
+			attrs[name] = value
+		}
+		attrs["Command"] = section.Command
+		dict, err := c.ParseCmd(section.Output, attrs)
+		results = append(results, TranscriptResult{Section: section, Dict: dict, Err: err})
+	}
+	return results
+}
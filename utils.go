@@ -3,6 +3,7 @@ package gotextfsm
 import (
 	"fmt"
 	"regexp"
+	"regexp/syntax"
 	"strings"
 	"unicode"
 )
@@ -27,21 +28,42 @@ func GetNamedMatches(r *regexp.Regexp, s string) map[string]string {
 // Given a regular expression with named groups
 // ex. (?P<name>\w+)\s+(?P<age>\d+)
 // Return the names e.g. ["name", "age"]
+// Names are identifiers made of letters, digits and underscores, given as (?P<name>...).
+// Returns error if the regular expression is invalid, if a name is used more than once or if a named group
+// is nested inside another named group.
 func GetGroupNames(r string) ([]string, error) {
-	r1 := regexp.MustCompile("\\(\\?P\\<([a-z]+)\\>")
-	m := r1.FindAllStringSubmatch(r, -1)
+	re, err := syntax.Parse(r, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
 	output := make([]string, 0)
-	if m == nil || len(m) == 0 {
-		return output, nil
+	if err := collectGroupNames(re, "", &output); err != nil {
+		return nil, err
 	}
-	for _, arr := range m {
-		if FindIndex(output, arr[1]) >= 0 {
-			return nil, fmt.Errorf("Duplicate name '%s'", arr[1])
+	return output, nil
+}
+
+// collectGroupNames appends the names of the named groups in re to output, in the order of the groups.
+// parent is the name of the enclosing named group. Empty if there is none.
+func collectGroupNames(re *syntax.Regexp, parent string, output *[]string) error {
+	if re.Op == syntax.OpCapture && re.Name != "" {
+		if parent != "" {
+			return fmt.Errorf("Nested named group '%s' inside '%s' is not supported", re.Name, parent)
+		}
+		if FindIndex(*output, re.Name) >= 0 {
+			return fmt.Errorf("Duplicate name '%s'", re.Name)
 		}
-		output = append(output, arr[1])
+		*output = append(*output, re.Name)
+		parent = re.Name
 	}
-	return output, nil
+	for _, sub := range re.Sub {
+		if err := collectGroupNames(sub, parent, output); err != nil {
+			return err
+		}
+	}
+	return nil
 }
+
 func FindIndex(arr []string, elem string) int {
 	for i, v := range arr {
 		if v == elem {
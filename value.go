@@ -22,8 +22,12 @@ type TextFSMValue struct {
 	Template       string
 	Name           string
 	Options        []string
+	Level          string // Level of records the value belongs to (See 'Level=' option). Empty for top level records.
+	Table          string // Table the value belongs to (See 'Table=' option). Empty for the default table (Dict).
 	curval         interface{}
 	filldown_value interface{}
+	group_names    []string
+	origin         string // File and line the value was read from. See ParseFS.
 }
 
 func isValidOption(str string) bool {
@@ -34,7 +38,8 @@ func isValidOption(str string) bool {
 	return false
 }
 
-func (value *TextFSMValue) Parse(input string, line_num int) error {
+func (value *TextFSMValue) Parse(input string, line_num int) (err error) {
+	defer recoverInternalError("ParseValue", &err)
 	tokens := strings.Fields(input)
 	if len(tokens) < 3 {
 		return fmt.Errorf("%d Line: Expect at least 3 tokens on line.", line_num)
@@ -45,6 +50,28 @@ func (value *TextFSMValue) Parse(input string, line_num int) error {
 		// ex: Value Filledown,Required interface (.*)
 		options := tokens[1]
 		for _, option := range strings.Split(options, ",") {
+			if strings.HasPrefix(option, "Level=") {
+				if value.Level != "" {
+					return fmt.Errorf("%d Line: Duplicate option Level", line_num)
+				}
+				value.Level = strings.TrimPrefix(option, "Level=")
+				if !LEVEL_RE.MatchString(value.Level) {
+					return fmt.Errorf("Line %d: Invalid level '%s'", line_num, value.Level)
+				}
+				value.Options = append(value.Options, option)
+				continue
+			}
+			if strings.HasPrefix(option, "Table=") {
+				if value.Table != "" {
+					return fmt.Errorf("%d Line: Duplicate option Table", line_num)
+				}
+				value.Table = strings.TrimPrefix(option, "Table=")
+				if !TABLE_RE.MatchString(value.Table) {
+					return fmt.Errorf("Line %d: Invalid table '%s'", line_num, value.Table)
+				}
+				value.Options = append(value.Options, option)
+				continue
+			}
 			if !isValidOption(option) {
 				return fmt.Errorf("Line %d: Invalid option %s", line_num, option)
 			}
@@ -54,13 +81,25 @@ func (value *TextFSMValue) Parse(input string, line_num int) error {
 			}
 			value.Options = append(value.Options, option)
 		}
+		if value.Level != "" && FindIndex(value.Options, "Fillup") >= 0 {
+			return fmt.Errorf("Line %d: Option Fillup can not be used with Level", line_num)
+		}
+		if value.Table != "" && FindIndex(value.Options, "Fillup") >= 0 {
+			return fmt.Errorf("Line %d: Option Fillup can not be used with Table", line_num)
+		}
+		if value.Level != "" && value.Table != "" {
+			return fmt.Errorf("Line %d: Options Level and Table can not be used together", line_num)
+		}
 		value.Name = tokens[2]
-		value.Regex = strings.Join(tokens[3:], " ")
+		// The regex is the rest of the line as is. As whitespace in it is significant.
+		if len(tokens) > 3 {
+			value.Regex = TrimRightSpace(input[fieldSpans(input)[3].Start:])
+		}
 	} else {
 		// Format: Value Name Regular Expression
 		// ex: Value interface (.*)
 		value.Name = tokens[1]
-		value.Regex = strings.Join(tokens[2:], " ")
+		value.Regex = TrimRightSpace(input[fieldSpans(input)[2].Start:])
 	}
 	if len(value.Name) > MAX_NAME_LENG {
 		return fmt.Errorf("%d Line: Invalid Value name '%s' or name too long.", line_num, value.Name)
@@ -71,13 +110,21 @@ func (value *TextFSMValue) Parse(input string, line_num int) error {
 	if _, err := regexp.Compile(value.Regex); err != nil {
 		return fmt.Errorf("Line %d: Invalid regular expression '%s'. Error: '%s'", line_num, value.Regex, err.Error())
 	}
-	if _, err := GetGroupNames(value.Regex); err != nil {
+	group_names, err := GetGroupNames(value.Regex)
+	if err != nil {
 		return fmt.Errorf("Line %d: Invalid group names. Error: %s", line_num, err.Error())
 	}
+	value.group_names = group_names
 	value.Template = regexp.MustCompile(`^\(`).ReplaceAllString(value.Regex, fmt.Sprintf("(?P<%s>", value.Name))
 	return nil
 }
 
+// Origin returns the file and the line of the template the value was read from. ex. 'base.textfsm:3'
+// Empty if the template was not read by ParseFS.
+func (v *TextFSMValue) Origin() string {
+	return v.origin
+}
+
 // String() returns a string representation of the value
 func (v *TextFSMValue) String() string {
 	var sb strings.Builder
@@ -90,20 +137,27 @@ func (v *TextFSMValue) String() string {
 	return sb.String()
 }
 
-func (v *TextFSMValue) processScalarValue(newval string) {
+func (v *TextFSMValue) processScalarValue(newval string) error {
 	var finalval interface{} = nil
 	if FindIndex(v.Options, "List") >= 0 {
 		// If the value is 'List', add the new value to the current value.
 		if v.curval == nil {
 			if FindIndex(v.Options, "Filldown") >= 0 && v.filldown_value != nil {
 				// curval is null. But there is a filldown value. Append to filldown value
-				finalval = append(v.filldown_value.([]string), newval)
+				list, ok := v.filldown_value.([]string)
+				if !ok {
+					return v.typeError(v.filldown_value)
+				}
+				finalval = append(list, newval)
 			} else {
-				finalval = make([]string, 0)
-				finalval = append(finalval.([]string), newval)
+				finalval = []string{newval}
 			}
 		} else {
-			finalval = append(v.curval.([]string), newval)
+			list, ok := v.curval.([]string)
+			if !ok {
+				return v.typeError(v.curval)
+			}
+			finalval = append(list, newval)
 		}
 	} else {
 		finalval = newval
@@ -117,31 +171,33 @@ func (v *TextFSMValue) processScalarValue(newval string) {
 		}
 	}
 	v.curval = finalval
+	return nil
 }
 
-func (v *TextFSMValue) processMapValue(newval map[string]string) {
-	newmap := make(map[string]string)
-	var_names, err := GetGroupNames(v.Regex)
-	if err != nil {
-		panic(err)
-	}
-	for _, name := range var_names {
-		newmap[name] = newval[name]
-	}
+// processMapValue processes the match of a value with nested match groups.
+// newmap holds the matches of the nested groups of the value (See group_names) and becomes part of the value.
+func (v *TextFSMValue) processMapValue(newmap map[string]string) error {
 	var finalval interface{} = newmap
 	if FindIndex(v.Options, "List") >= 0 {
 		// If the value is 'List', add the new value to the current value.
-		if newval != nil && len(newval) > 0 {
+		if newmap != nil && len(newmap) > 0 {
 			if v.curval == nil {
 				if FindIndex(v.Options, "Filldown") >= 0 && v.filldown_value != nil {
 					// curval is null. But there is a filldown value. Append to filldown value
-					finalval = append(v.filldown_value.([]map[string]string), newmap)
+					list, ok := v.filldown_value.([]map[string]string)
+					if !ok {
+						return v.typeError(v.filldown_value)
+					}
+					finalval = append(list, newmap)
 				} else {
-					finalval = make([]map[string]string, 0)
-					finalval = append(finalval.([]map[string]string), newmap)
+					finalval = []map[string]string{newmap}
 				}
 			} else {
-				finalval = append(v.curval.([]map[string]string), newmap)
+				list, ok := v.curval.([]map[string]string)
+				if !ok {
+					return v.typeError(v.curval)
+				}
+				finalval = append(list, newmap)
 			}
 		}
 	}
@@ -154,22 +210,31 @@ func (v *TextFSMValue) processMapValue(newval map[string]string) {
 		}
 	}
 	v.curval = finalval
+	return nil
 }
 
-func (v *TextFSMValue) onAppendRecord() ON_RECORD_TYPE {
+func (v *TextFSMValue) onAppendRecord() (ON_RECORD_TYPE, error) {
 	if FindIndex(v.Options, "Required") >= 0 {
-		if v.isEmptyValue(v.curval) {
+		empty, err := v.isEmptyValue(v.curval)
+		if err != nil {
+			return SKIP_RECORD, err
+		}
+		if empty {
 			if FindIndex(v.Options, "Filldown") >= 0 {
-				if v.isEmptyValue(v.filldown_value) {
-					return SKIP_RECORD
+				empty, err = v.isEmptyValue(v.filldown_value)
+				if err != nil {
+					return SKIP_RECORD, err
+				}
+				if empty {
+					return SKIP_RECORD, nil
 				} else {
-					return CONTINUE
+					return CONTINUE, nil
 				}
 			}
-			return SKIP_RECORD
+			return SKIP_RECORD, nil
 		}
 	}
-	return CONTINUE
+	return CONTINUE, nil
 }
 
 func (v *TextFSMValue) clearValue(all bool) {
@@ -179,24 +244,28 @@ func (v *TextFSMValue) clearValue(all bool) {
 	}
 }
 
-func (v *TextFSMValue) getFinalValue() interface{} {
-	if v.isEmptyValue(v.curval) && FindIndex(v.Options, "Filldown") >= 0 {
-		return v.getFinalValueInternal(v.filldown_value)
+func (v *TextFSMValue) getFinalValue() (interface{}, error) {
+	empty, err := v.isEmptyValue(v.curval)
+	if err != nil {
+		return nil, err
 	}
-	return v.getFinalValueInternal(v.curval)
+	if empty && FindIndex(v.Options, "Filldown") >= 0 {
+		return v.getFinalValueInternal(v.filldown_value), nil
+	}
+	return v.getFinalValueInternal(v.curval), nil
 }
 func (v *TextFSMValue) getFinalValueInternal(val interface{}) interface{} {
 	if val == nil {
 		if idx := FindIndex(v.Options, "List"); idx >= 0 {
-			if strings.Contains(v.Regex, "(?P") {
-				// If the regex contains (?P
+			if v.isMap() {
+				// If the regex contains named groups
 				// ex: Value List ((?P<name>\w+)\s+(?P<age>\d+))
 				// This will be an array of maps.
 				return make([]map[string]string, 0)
 			}
 			// Else, it will be an array of strings
 			return make([]string, 0)
-		} else if strings.Contains(v.Regex, "(?P") {
+		} else if v.isMap() {
 			return make(map[string]string)
 		} else {
 			return ""
@@ -205,21 +274,31 @@ func (v *TextFSMValue) getFinalValueInternal(val interface{}) interface{} {
 	return val
 }
 
-func (v *TextFSMValue) isEmptyValue(val interface{}) bool {
+func (v *TextFSMValue) isEmptyValue(val interface{}) (bool, error) {
 	if val == nil {
-		return true
+		return true, nil
 	}
 	switch val.(type) {
 	case string:
-		return val.(string) == ""
+		return val.(string) == "", nil
 	case []string:
-		return len(val.([]string)) == 0
+		return len(val.([]string)) == 0, nil
 	case map[string]string:
-		return len(val.(map[string]string)) == 0
+		return len(val.(map[string]string)) == 0, nil
 	case []map[string]string:
-		return len(val.([]map[string]string)) == 0
+		return len(val.([]map[string]string)) == 0, nil
 	default:
-		panic(fmt.Sprintf("Unknown data type %v for %s", reflect.TypeOf(val), v.Name))
+		return false, v.typeError(val)
 	}
+}
+
+// isMap returns true if the regex of the value contains named groups. The value is then a map (or a List of maps)
+// of the names to the matches of the groups.
+func (v *TextFSMValue) isMap() bool {
+	return len(v.group_names) > 0
+}
 
+// typeError returns the error for a value holding data of a type it can never hold.
+func (v *TextFSMValue) typeError(val interface{}) error {
+	return fmt.Errorf("Unknown data type %v for %s", reflect.TypeOf(val), v.Name)
 }
@@ -1,7 +1,9 @@
 package gotextfsm
 
 import (
+	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -57,6 +59,38 @@ func TestValueParse(t *testing.T) {
 
 }
 
+func TestGetGroupNames(t *testing.T) {
+	testcases := []struct {
+		regex string
+		names []string
+		err   string
+	}{
+		{regex: `(\S+)`, names: []string{}},
+		{regex: `((?P<name>\w+)\s+(?P<age>\d+))`, names: []string{"name", "age"}},
+		{regex: `((?P<Name>\w+)\s+(?P<if_name2>\S+)\s+(?P<_State>\w+))`, names: []string{"Name", "if_name2", "_State"}},
+		{regex: `((?P<name>\w+)|(?P<ip>\d+(\.\d+){3}))`, names: []string{"name", "ip"}},
+		{regex: `((?P<name>\w+)\s+(?P<name>\d+))`, err: "Duplicate name 'name'"},
+		{regex: `((?P<name>(?P<first>\w+)\s+\w+))`, err: "Nested named group 'first' inside 'name' is not supported"},
+		{regex: `((?P<name>\w+)`, err: "missing closing )"},
+	}
+	for _, tc := range testcases {
+		names, err := GetGroupNames(tc.regex)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("'%s' failed. Expected error '%s'. Got '%v'", tc.regex, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("'%s' failed. Expected no error. But found error %s", tc.regex, err)
+			continue
+		}
+		if !reflect.DeepEqual(names, tc.names) {
+			t.Errorf("'%s' failed. Names dont match (%v, %v)", tc.regex, tc.names, names)
+		}
+	}
+}
+
 var valTestCases = []valTestCase{
 	{
 		input: "Hello World",
@@ -142,6 +176,17 @@ var valTestCases = []valTestCase{
 		name:  "beer",
 		regex: `(boo[(]hoo)`,
 	},
+	{
+		input: "Value beer (boo  hoo\tbar)  ",
+		name:  "beer",
+		regex: "(boo  hoo\tbar)",
+	},
+	{
+		input:   "Value Required  beer   (boo  hoo)",
+		name:    "beer",
+		regex:   "(boo  hoo)",
+		options: map[string]bool{"Required": true},
+	},
 	{
 		input: `Value beer (boo\[)\]hoo)`,
 		err:   regexp.MustCompile(`.+`),
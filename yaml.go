@@ -0,0 +1,309 @@
+package gotextfsm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// yamlLine is a significant line of a YAML document.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// parseYAML parses the subset of YAML used by the expected results of template tests.
+// (Those written by WriteYAML and those of ntc-templates)
+// Supported are block mappings, block sequences, plain, single quoted and double quoted scalars,
+// flow sequences and mappings of scalars (ex. [] and {}), comments and document markers.
+// All the scalars are returned as strings, except null and ~ which are returned as nil.
+//     Returns:
+//       map[string]interface{}, []interface{}, string or nil. error if the document is not valid.
+func parseYAML(data string) (interface{}, error) {
+	lines := make([]yamlLine, 0)
+	for i, text := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		text = TrimRightSpace(stripYAMLComment(text))
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || text == "---" || text == "..." {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("%d Line: Tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	val, idx, err := parseYAMLBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if idx < len(lines) {
+		return nil, fmt.Errorf("%d Line: Unexpected indentation", lines[idx].num)
+	}
+	return val, nil
+}
+
+// stripYAMLComment removes the comment (if any) from the line. '#' starts a comment only
+// at the start of the line or after a space, outside quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' '):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseYAMLBlock parses the block (sequence or mapping) starting at lines[idx] with the given indentation.
+// Returns the value and the index of the first line after the block.
+func parseYAMLBlock(lines []yamlLine, idx int, indent int) (interface{}, int, error) {
+	if isYAMLSequenceItem(lines[idx].text) {
+		return parseYAMLSequence(lines, idx, indent)
+	}
+	if _, _, ok := splitYAMLKey(lines[idx].text); ok {
+		return parseYAMLMapping(lines, idx, indent)
+	}
+	val, err := parseYAMLScalar(lines[idx].text, lines[idx].num)
+	return val, idx + 1, err
+}
+
+func parseYAMLSequence(lines []yamlLine, idx int, indent int) (interface{}, int, error) {
+	seq := make([]interface{}, 0)
+	for idx < len(lines) && lines[idx].indent == indent && isYAMLSequenceItem(lines[idx].text) {
+		line := lines[idx]
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			// Item is the nested block in the following lines (if any).
+			if idx+1 < len(lines) && lines[idx+1].indent > indent {
+				val, next, err := parseYAMLBlock(lines, idx+1, lines[idx+1].indent)
+				if err != nil {
+					return nil, idx, err
+				}
+				seq = append(seq, val)
+				idx = next
+			} else {
+				seq = append(seq, nil)
+				idx++
+			}
+			continue
+		}
+		// The item starts on the same line. Treat the rest of the line as a line of its own,
+		// indented to where it starts. (ex. '- key: value' followed by '  key2: value2')
+		offset := len(line.text) - len(rest)
+		lines[idx] = yamlLine{num: line.num, indent: indent + offset, text: rest}
+		val, next, err := parseYAMLBlock(lines, idx, indent+offset)
+		if err != nil {
+			return nil, idx, err
+		}
+		seq = append(seq, val)
+		idx = next
+	}
+	if idx < len(lines) && lines[idx].indent > indent {
+		return nil, idx, fmt.Errorf("%d Line: Unexpected indentation", lines[idx].num)
+	}
+	return seq, idx, nil
+}
+
+func parseYAMLMapping(lines []yamlLine, idx int, indent int) (interface{}, int, error) {
+	mapping := make(map[string]interface{})
+	for idx < len(lines) && lines[idx].indent == indent {
+		line := lines[idx]
+		if isYAMLSequenceItem(line.text) {
+			break
+		}
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, idx, fmt.Errorf("%d Line: Expected 'key: value'", line.num)
+		}
+		if _, exists := mapping[key]; exists {
+			return nil, idx, fmt.Errorf("%d Line: Duplicate key '%s'", line.num, key)
+		}
+		idx++
+		if rest != "" {
+			val, err := parseYAMLScalar(rest, line.num)
+			if err != nil {
+				return nil, idx, err
+			}
+			mapping[key] = val
+			continue
+		}
+		// Value is the nested block. A sequence may be at the same indentation as the key.
+		if idx < len(lines) && (lines[idx].indent > indent || (lines[idx].indent == indent && isYAMLSequenceItem(lines[idx].text))) {
+			val, next, err := parseYAMLBlock(lines, idx, lines[idx].indent)
+			if err != nil {
+				return nil, idx, err
+			}
+			mapping[key] = val
+			idx = next
+		} else {
+			mapping[key] = nil
+		}
+	}
+	if idx < len(lines) && lines[idx].indent > indent {
+		return nil, idx, fmt.Errorf("%d Line: Unexpected indentation", lines[idx].num)
+	}
+	return mapping, idx, nil
+}
+
+// splitYAMLKey splits 'key: value' into the key and the value. ok is false if the text is not of that form.
+func splitYAMLKey(text string) (key string, rest string, ok bool) {
+	end := -1
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, `'`) {
+		quoted, n, err := readYAMLQuoted(text)
+		if err != nil {
+			return "", "", false
+		}
+		if !strings.HasPrefix(text[n:], ":") {
+			return "", "", false
+		}
+		key = quoted
+		end = n
+	} else {
+		if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+			return "", "", false
+		}
+		end = strings.Index(text, ": ")
+		if end < 0 {
+			if !strings.HasSuffix(text, ":") {
+				return "", "", false
+			}
+			end = len(text) - 1
+		}
+		key = text[:end]
+	}
+	rest = strings.TrimSpace(text[end+1:])
+	if end+1 < len(text) && text[end+1] != ' ' {
+		return "", "", false
+	}
+	return key, rest, true
+}
+
+// readYAMLQuoted reads the quoted scalar at the start of text.
+// Returns the unquoted string and the number of bytes read.
+func readYAMLQuoted(text string) (string, int, error) {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		if quote == '"' && text[i] == '\\' {
+			i++
+			continue
+		}
+		if text[i] != quote {
+			continue
+		}
+		if quote == '\'' {
+			if i+1 < len(text) && text[i+1] == '\'' {
+				// '' is an escaped quote.
+				i++
+				continue
+			}
+			return strings.ReplaceAll(text[1:i], "''", "'"), i + 1, nil
+		}
+		var str string
+		if err := json.Unmarshal([]byte(text[:i+1]), &str); err != nil {
+			return "", 0, fmt.Errorf("Invalid double quoted string %s", text[:i+1])
+		}
+		return str, i + 1, nil
+	}
+	return "", 0, fmt.Errorf("Missing closing quote in %s", text)
+}
+
+func parseYAMLScalar(text string, line_num int) (interface{}, error) {
+	switch {
+	case text == "null" || text == "~":
+		return nil, nil
+	case strings.HasPrefix(text, `"`) || strings.HasPrefix(text, `'`):
+		str, n, err := readYAMLQuoted(text)
+		if err != nil {
+			return nil, fmt.Errorf("%d Line: %s", line_num, err.Error())
+		}
+		if strings.TrimSpace(text[n:]) != "" {
+			return nil, fmt.Errorf("%d Line: Unexpected text after quoted string", line_num)
+		}
+		return str, nil
+	case strings.HasPrefix(text, "["):
+		return parseYAMLFlow(text, "[", "]", line_num)
+	case strings.HasPrefix(text, "{"):
+		return parseYAMLFlow(text, "{", "}", line_num)
+	case text == "|" || text == ">" || strings.HasPrefix(text, "|-") || strings.HasPrefix(text, ">-"):
+		return nil, fmt.Errorf("%d Line: Block scalars are not supported", line_num)
+	}
+	return text, nil
+}
+
+// parseYAMLFlow parses a flow sequence or mapping of scalars in a single line. ex. ["a", "b"] or {a: "1"}
+func parseYAMLFlow(text string, open string, close string, line_num int) (interface{}, error) {
+	if !strings.HasSuffix(text, close) {
+		return nil, fmt.Errorf("%d Line: Missing '%s'", line_num, close)
+	}
+	body := strings.TrimSpace(text[1 : len(text)-1])
+	items := make([]string, 0)
+	for body != "" {
+		var item string
+		if body[0] == '"' || body[0] == '\'' {
+			_, n, err := readYAMLQuoted(body)
+			if err != nil {
+				return nil, fmt.Errorf("%d Line: %s", line_num, err.Error())
+			}
+			end := strings.Index(body[n:], ",")
+			if end < 0 {
+				end = len(body) - n
+			}
+			item = body[:n+end]
+			body = body[n+end:]
+		} else {
+			end := strings.Index(body, ",")
+			if end < 0 {
+				end = len(body)
+			}
+			item = body[:end]
+			body = body[end:]
+		}
+		items = append(items, strings.TrimSpace(item))
+		body = strings.TrimSpace(strings.TrimPrefix(body, ","))
+	}
+	if open == "[" {
+		seq := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			if strings.HasPrefix(item, "[") || strings.HasPrefix(item, "{") {
+				return nil, fmt.Errorf("%d Line: Nested flow collections are not supported", line_num)
+			}
+			val, err := parseYAMLScalar(item, line_num)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, val)
+		}
+		return seq, nil
+	}
+	mapping := make(map[string]interface{})
+	for _, item := range items {
+		key, rest, ok := splitYAMLKey(item)
+		if !ok {
+			return nil, fmt.Errorf("%d Line: Expected 'key: value' in '%s'", line_num, item)
+		}
+		val, err := parseYAMLScalar(rest, line_num)
+		if err != nil {
+			return nil, err
+		}
+		mapping[key] = val
+	}
+	return mapping, nil
+}
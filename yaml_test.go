@@ -0,0 +1,104 @@
+package gotextfsm
+
+import (
+	"reflect"
+	"testing"
+)
+
+type yamlTestCase struct {
+	input  string
+	output interface{}
+	err    bool
+}
+
+func TestParseYAML(t *testing.T) {
+	for _, tc := range yamlTestCases {
+		output, err := parseYAML(tc.input)
+		if tc.err {
+			if err == nil {
+				t.Errorf("'%s' failed. Expected error, but none found", tc.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("'%s' failed. Expected no error. But found error %s", tc.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(output, tc.output) {
+			t.Errorf("'%s' failed. Outputs dont match (%#v, %#v)", tc.input, tc.output, output)
+		}
+	}
+	t.Logf("Executed %d test cases", len(yamlTestCases))
+}
+
+var yamlTestCases = []yamlTestCase{
+	{input: "", output: nil},
+	{input: "---\n# Only a comment\n", output: nil},
+	{input: "[]", output: []interface{}{}},
+	{input: "plain text", output: "plain text"},
+	{
+		input: `- device: "sw1"
+  vlans:
+    - "10"
+    - '20'
+  address:
+    ip: "10.0.0.1"
+    mask: 24
+  peers:
+    - name: "a"
+      asn: "100"
+    -
+      name: b
+      asn: "200"
+  description: "Uplink, \"core\" # not a comment"
+- device: sw2 # comment
+  vlans: []
+  address: {}
+  empty:
+`,
+		output: []interface{}{
+			map[string]interface{}{
+				"device":  "sw1",
+				"vlans":   []interface{}{"10", "20"},
+				"address": map[string]interface{}{"ip": "10.0.0.1", "mask": "24"},
+				"peers": []interface{}{
+					map[string]interface{}{"name": "a", "asn": "100"},
+					map[string]interface{}{"name": "b", "asn": "200"},
+				},
+				"description": `Uplink, "core" # not a comment`,
+			},
+			map[string]interface{}{
+				"device":  "sw2",
+				"vlans":   []interface{}{},
+				"address": map[string]interface{}{},
+				"empty":   nil,
+			},
+		},
+	},
+	{
+		// ntc-templates style
+		input: `---
+parsed_sample:
+- interface: "Gi0/1"
+  'it''s': ["a", 'b, c', d]
+  flow: {ip: "1.1.1.1", mask: 24}
+  url: http://example.com/a:b
+`,
+		output: map[string]interface{}{
+			"parsed_sample": []interface{}{
+				map[string]interface{}{
+					"interface": "Gi0/1",
+					"it's":      []interface{}{"a", "b, c", "d"},
+					"flow":      map[string]interface{}{"ip": "1.1.1.1", "mask": "24"},
+					"url":       "http://example.com/a:b",
+				},
+			},
+		},
+	},
+	{input: "a: 1\n  b: 2\n", err: true},
+	{input: "a: 1\na: 2\n", err: true},
+	{input: "a: \"unterminated\n", err: true},
+	{input: "a: [1, 2\n", err: true},
+	{input: "a: |\n  text\n", err: true},
+	{input: "- a\nb: 1\n", err: true},
+}